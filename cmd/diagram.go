@@ -0,0 +1,61 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+var diagramFormats []string
+
+// diagramCmd represents the diagram command
+var diagramCmd = &cobra.Command{
+	Use:   "diagram",
+	Short: "Create gateway topology diagrams.",
+	Long: `Create gateway topology diagrams (gateway -> Kong service(prod/mock) -> route -> operation).
+--format selects the outputs (mermaid / dot). Nodes are colored by implementation status(green: production, orange: mock),
+and routes switched by the mock header are drawn with dashed lines.
+The mermaid diagram can also be embedded in api-list.md with 'list --diagram'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := loadApiList()
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 出力形式のチェック
+		for _, format := range diagramFormats {
+			if !slices.Contains([]string{"mermaid", "dot"}, format) {
+				return fmt.Errorf("unsupported format: %s", format)
+			}
+		}
+
+		// PROCESS: ダイアグラム出力
+		if slices.Contains(diagramFormats, "mermaid") {
+			err = apiList.DiagramMermaid(filepath.Join(distDir, "topology.mmd"))
+			if err != nil {
+				return err
+			}
+		}
+		if slices.Contains(diagramFormats, "dot") {
+			err = apiList.DiagramDot(filepath.Join(distDir, "topology.dot"))
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Println("***command[diagram] completed.")
+		return nil
+	},
+}
+
+func init() {
+	// INFO:フラグ値を変数にBind
+	diagramCmd.Flags().StringSliceVarP(&diagramFormats, "format", "F", []string{"mermaid", "dot"}, "output formats (mermaid / dot).")
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/teru-0529/api-forge/model"
+)
+
+var baseRef string
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [old.yaml new.yaml]",
+	Short: "Detect breaking changes between two openapi versions.",
+	Long: `Detect breaking changes between two openapi versions.
+Compares the two files given as arguments (reported under the info.title of the new file), or with --base-ref, the openapi file of every service in the setting file
+with its version at the git commit.
+Breaking: removed operation/response/response field/response header, new required parameter/request field,
+narrowed enum, changed type. Other changes are reported as non-breaking.
+Writes api-changelog.md and exits with non-zero status when breaking changes are found.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if baseRef != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: 比較
+		changes := []model.SpecChange{}
+		title := baseRef
+		if baseRef == "" {
+			title = fmt.Sprintf("%s => %s", args[0], args[1])
+			base, err := readSpec(args[0])
+			if err != nil {
+				return err
+			}
+			head, err := readSpec(args[1])
+			if err != nil {
+				return err
+			}
+			changes, err = model.DiffSpecs("", base, head)
+			if err != nil {
+				return err
+			}
+		} else {
+			// PROCESS: APIファイルの読み込み
+			apiList, err := loadApiList()
+			if err != nil {
+				return err
+			}
+			for _, service := range apiList.Services {
+				base, err := gitShow(baseRef, service.OpenapiPath)
+				if err != nil {
+					return err
+				}
+				head, err := readSpec(service.OpenapiPath)
+				if err != nil {
+					return err
+				}
+				items, err := model.DiffSpecs(service.ServiceName, base, head)
+				if err != nil {
+					return err
+				}
+				changes = append(changes, items...)
+			}
+		}
+
+		// PROCESS: 出力
+		for _, change := range changes {
+			kind := "non-breaking"
+			if change.Breaking {
+				kind = "BREAKING"
+			}
+			fmt.Printf("  %-12s: %s %s %s\n", kind, change.Service, change.OperationId, change.Message)
+		}
+		err := model.SpecChangelogMd(filepath.Join(distDir, "api-changelog.md"), title, changes)
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 破壊的変更がある場合はエラー終了
+		if count := model.BreakingCount(changes); count > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d breaking changes detected", count)
+		}
+
+		fmt.Println("***command[diff] completed.")
+		return nil
+	},
+}
+
+func init() {
+	// INFO:フラグ値を変数にBind
+	diffCmd.Flags().StringVar(&baseRef, "base-ref", "", "git commit to compare the openapi files of the setting file with.")
+}
+
+// FUNCTION: openapiファイルの読込み
+func readSpec(path string) ([]byte, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file: %w", err)
+	}
+	return source, nil
+}
+
+// FUNCTION: git上の過去バージョンの読込み(存在しない場合はnil)
+func gitShow(ref string, path string) ([]byte, error) {
+	// INFO: `./`付きのパスはgitの作業ディレクトリからの相対パスとして扱われる
+	object := fmt.Sprintf("%s:./%s", ref, filepath.Base(path))
+	dir := filepath.Dir(path)
+
+	// PROCESS: 存在チェック(コミットが存在しファイルのみ存在しない場合は新規ファイル)
+	if git(dir, "cat-file", "-e", object) != nil {
+		if git(dir, "cat-file", "-e", ref+"^{commit}") == nil {
+			return nil, nil
+		}
+	}
+
+	// PROCESS: 読込み
+	command := exec.Command("git", "show", object)
+	command.Dir = dir
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	out, err := command.Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read '%s' at %s: %s", path, ref, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
+
+// FUNCTION: gitコマンドの実行(終了コードのみ判定する)
+func git(dir string, args ...string) error {
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	return command.Run()
+}
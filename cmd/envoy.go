@@ -0,0 +1,38 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// envoyCmd represents the envoy command
+var envoyCmd = &cobra.Command{
+	Use:   "envoy",
+	Short: "Create Envoy RouteConfiguration and clusters.",
+	Long:  "Create Envoy RouteConfiguration(rds.yaml) and clusters(cds.yaml) as file-based xDS resources.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := loadApiList()
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 設定ファイル出力
+		err = apiList.Envoy(filepath.Join(distDir, "envoy"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("***command[envoy] completed.")
+		return nil
+	},
+}
+
+func init() {
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/teru-0529/api-forge/model"
 )
 
 // fixtureCmd represents the fixture command
@@ -18,7 +17,7 @@ var fixtureCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		// PROCESS: APIファイルの読み込み
-		apiList, err := model.New(settingFile)
+		apiList, err := loadApiList()
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// htmlCmd represents the html command
+var htmlCmd = &cobra.Command{
+	Use:   "html",
+	Short: "Create API catalog html.",
+	Long: `Create API catalog html.
+The catalog is a single self-contained file (no external assets), with search, status filters and api details.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := loadApiList()
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: カタログ出力
+		err = apiList.CatalogHtml(filepath.Join(distDir, "api-catalog.html"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("***command[html] completed.")
+		return nil
+	},
+}
+
+func init() {
+}
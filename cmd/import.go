@@ -0,0 +1,82 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/teru-0529/api-forge/model"
+)
+
+var (
+	fromFile   string
+	syncStatus bool
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import existing settings into the setting file.",
+	Long:  "Import existing settings into the setting file.",
+}
+
+// importKongCmd represents the import kong command
+var importKongCmd = &cobra.Command{
+	Use:   "kong",
+	Short: "Import existing Kong service/route ids.",
+	Long: `Import existing Kong service/route ids.
+Routes are matched to operations by method and path regex, and their ids are written to kongId/serviceId.
+implemented is kept unless --sync-status is set, and a warning is shown when the deployed route differs.
+--from accepts a sql dump (INSERT or COPY) or a decK yaml file.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: 絞り込み条件のチェック(設定ファイル全体を保存するため)
+		if err := rejectFilter("import kong"); err != nil {
+			return err
+		}
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := model.New(settingFile)
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: Kongの設定の読み込み
+		state, err := model.LoadKongState(apiList.AclOption(), fromFile)
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 取り込み
+		result := apiList.ImportKong(state, syncStatus)
+		for _, line := range result.Matched {
+			fmt.Printf("  matched  : %s\n", line)
+		}
+		for _, line := range result.UnmatchedApis {
+			fmt.Printf("  no route : %s\n", line)
+		}
+		for _, route := range result.UnmatchedRoutes {
+			fmt.Printf("  unmatched: %s\n", route)
+		}
+
+		// PROCESS: 設定ファイル保存
+		err = apiList.Write(settingFile)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("***command[import kong] completed. (matched: %d, unmatched routes: %d)\n", len(result.Matched), len(result.UnmatchedRoutes))
+		return nil
+	},
+}
+
+func init() {
+	importCmd.AddCommand(importKongCmd)
+
+	// INFO:フラグ値を変数にBind
+	importKongCmd.Flags().StringVarP(&fromFile, "from", "f", "", "kong dump file (.sql / .yaml).")
+	importKongCmd.Flags().BoolVar(&syncStatus, "sync-status", false, "set implemented by the service(prod / mock) of the deployed route.")
+	importKongCmd.MarkFlagRequired("from")
+}
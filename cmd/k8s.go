@@ -0,0 +1,47 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/teru-0529/api-forge/model"
+)
+
+var k8sOption model.K8sOption
+
+// k8sCmd represents the k8s command
+var k8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Create Kubernetes HTTPRoute/Ingress manifests.",
+	Long:  "Create Kubernetes HTTPRoute(Gateway API)/Ingress manifests, one multi-document yaml per service.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := loadApiList()
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: マニフェスト出力
+		err = apiList.K8sManifests(filepath.Join(distDir, "k8s"), k8sOption)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("***command[k8s] completed.")
+		return nil
+	},
+}
+
+func init() {
+	// INFO:フラグ値を変数にBind
+	k8sCmd.Flags().StringSliceVarP(&k8sOption.Kinds, "kind", "K", []string{model.HTTP_ROUTE}, "manifest kinds (httproute, ingress).")
+	k8sCmd.Flags().StringVarP(&k8sOption.Namespace, "namespace", "N", "", "namespace of the manifests.")
+	k8sCmd.Flags().StringVar(&k8sOption.Gateway, "gateway", "gateway", "parent gateway name of the HTTPRoute.")
+	k8sCmd.Flags().StringVar(&k8sOption.GatewayNamespace, "gateway-namespace", "", "parent gateway namespace of the HTTPRoute.")
+	k8sCmd.Flags().StringVar(&k8sOption.IngressClass, "ingress-class", "", "ingressClassName of the Ingress.")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/teru-0529/api-forge/model"
+)
+
+var dumpFiles []string
+
+// kongCmd represents the kong command
+var kongCmd = &cobra.Command{
+	Use:   "kong",
+	Short: "Inspect deployed Kong data.",
+	Long:  "Inspect deployed Kong data.",
+}
+
+// kongDiffCmd represents the kong diff command
+var kongDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Report drift between generated and deployed Kong/ACL data.",
+	Long: `Report drift between generated and deployed Kong/ACL data.
+Services, routes (path, method, target service, tags, headers) and acl resources are compared by id.
+--dump accepts a sql dump (INSERT or COPY), a decK yaml file or a json export, and can be repeated (e.g. kong and acl databases).
+acl tables are resolved by the 'acl' section of the setting file or --acl-schema, and are compared only when a dump contains them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: 絞り込み条件のチェック(稼働中のデータは全件のため)
+		if err := rejectFilter("kong diff"); err != nil {
+			return err
+		}
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := model.New(settingFile)
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 稼働中の設定の読み込み
+		option := apiList.AclOption()
+		if cmd.Flags().Changed("acl-schema") {
+			option.Schema = &aclSchema
+		}
+		state, err := model.LoadKongState(option, dumpFiles...)
+		if err != nil {
+			return err
+		}
+		if !state.HasAcl() {
+			log.Printf("WARNING: no acl tables in the dump, acl resources are not compared")
+		}
+
+		// PROCESS: 差異の検出
+		drifts, err := apiList.KongDrift(state, option)
+		if err != nil {
+			return err
+		}
+		counts := map[string]int{}
+		for _, drift := range drifts {
+			counts[drift.Status]++
+			fmt.Printf("  %-7s: %s %s %s\n", drift.Status, drift.Kind, drift.Id, drift.Name)
+		}
+
+		// PROCESS: 差異レポートの書き込み
+		err = apiList.KongDriftMd(filepath.Join(distDir, "kong-drift.md"), drifts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("***command[kong diff] completed. (missing: %d, extra: %d, changed: %d)\n",
+			counts[model.DRIFT_MISSING], counts[model.DRIFT_EXTRA], counts[model.DRIFT_CHANGED])
+		return nil
+	},
+}
+
+func init() {
+	kongCmd.AddCommand(kongDiffCmd)
+
+	// INFO:フラグ値を変数にBind
+	kongDiffCmd.Flags().StringSliceVarP(&dumpFiles, "dump", "d", nil, "deployed data files (.sql / .yaml / .json).")
+	kongDiffCmd.Flags().StringVar(&aclSchema, "acl-schema", "acl", "schema of the acl tables (empty for none).")
+	kongDiffCmd.MarkFlagRequired("dump")
+}
@@ -6,27 +6,63 @@ package cmd
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 
 	"github.com/spf13/cobra"
-	"github.com/teru-0529/api-forge/model"
+)
+
+var (
+	formats     []string
+	withDiagram bool
 )
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Create API list.",
-	Long:  "Create API list.",
+	Long: `Create API list.
+--format selects the outputs (md / tsv / json). The json document follows schema/api-list.schema.json.
+--diagram appends the gateway topology(mermaid) to api-list.md.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		// PROCESS: APIファイルの読み込み
-		apiList, err := model.New(settingFile)
+		apiList, err := loadApiList()
 		if err != nil {
 			return err
 		}
 
+		// PROCESS: 出力形式のチェック
+		for _, format := range formats {
+			if !slices.Contains([]string{"md", "tsv", "json"}, format) {
+				return fmt.Errorf("unsupported format: %s", format)
+			}
+		}
+
 		// PROCESS: リスト出力
-		apiList.ListMd(filepath.Join(distDir, "api-list.md"))
-		apiList.ListTsv(filepath.Join(distDir, "api-list.tsv"))
+		if slices.Contains(formats, "md") {
+			err = apiList.ListMd(filepath.Join(distDir, "api-list.md"))
+			if err != nil {
+				return err
+			}
+			if withDiagram {
+				err = apiList.AppendMermaid(filepath.Join(distDir, "api-list.md"))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if slices.Contains(formats, "tsv") {
+			err = apiList.ListTsv(filepath.Join(distDir, "api-list.tsv"))
+			if err != nil {
+				return err
+			}
+		}
+		if slices.Contains(formats, "json") {
+			err = apiList.ListJson(filepath.Join(distDir, "api-list.json"))
+			if err != nil {
+				return err
+			}
+		}
 
 		fmt.Println("***command[list] completed.")
 		return nil
@@ -34,4 +70,7 @@ var listCmd = &cobra.Command{
 }
 
 func init() {
+	// INFO:フラグ値を変数にBind
+	listCmd.Flags().StringSliceVarP(&formats, "format", "F", []string{"md", "tsv"}, "output formats (md / tsv / json).")
+	listCmd.Flags().BoolVar(&withDiagram, "diagram", false, "append the topology diagram(mermaid) to api-list.md.")
 }
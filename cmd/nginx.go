@@ -0,0 +1,42 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var listenPort int
+
+// nginxCmd represents the nginx command
+var nginxCmd = &cobra.Command{
+	Use:   "nginx",
+	Short: "Create nginx reverse-proxy configuration.",
+	Long:  "Create nginx reverse-proxy configuration for local development without Kong.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := loadApiList()
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 設定ファイル出力
+		err = apiList.NginxConf(filepath.Join(distDir, "nginx.conf"), listenPort)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("***command[nginx] completed.")
+		return nil
+	},
+}
+
+func init() {
+	// INFO:フラグ値を変数にBind
+	nginxCmd.Flags().IntVarP(&listenPort, "listen", "L", 8000, "listen port of the gateway.")
+}
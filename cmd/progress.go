@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/teru-0529/api-forge/model"
+)
+
+var historyFile string
+
+// progressCmd represents the progress command
+var progressCmd = &cobra.Command{
+	Use:   "progress",
+	Short: "Create implementation progress summary.",
+	Long: `Create implementation progress summary.
+Counts apis per service by status(production / mock) and lifecycle(active / deprecated), and writes
+progress.md (with progress bars), progress.json and svg badges(badges/<service>.svg, badges/total.svg).
+With --history, the summary is appended to the history file and the trend across runs is added to progress.md.
+--history cannot be combined with filters, so that the history only contains summaries of all apis.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: 絞り込み時は履歴を更新しない(全件の集計と混在するため)
+		if historyFile != "" {
+			if err := rejectFilter("progress --history"); err != nil {
+				return err
+			}
+		}
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := loadApiList()
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 集計
+		progress, err := apiList.Progress(time.Now().Truncate(time.Second))
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 履歴の読込み
+		history := []model.Progress{*progress}
+		if historyFile != "" {
+			history, err = model.LoadProgressHistory(historyFile)
+			if err != nil {
+				return err
+			}
+			history = append(history, *progress)
+		}
+
+		// PROCESS: 出力
+		badgeDir := filepath.Join(distDir, "badges")
+		err = progress.Badges(badgeDir)
+		if err != nil {
+			return err
+		}
+		err = progress.Json(filepath.Join(distDir, "progress.json"))
+		if err != nil {
+			return err
+		}
+		err = progress.Md(filepath.Join(distDir, "progress.md"), badgeDir, history)
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 履歴の保存(出力がすべて成功した場合のみ)
+		if historyFile != "" {
+			err = model.SaveProgressHistory(historyFile, history)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Println("***command[progress] completed.")
+		return nil
+	},
+}
+
+func init() {
+	// INFO:フラグ値を変数にBind
+	progressCmd.Flags().StringVarP(&historyFile, "history", "H", "", "history file path(json). the summary of this run is appended.")
+}
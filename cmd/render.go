@@ -0,0 +1,50 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateFile string
+	renderOut    string
+)
+
+// renderCmd represents the render command
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render a user-defined text/template.",
+	Long: `Render a user-defined text/template.
+The template receives the same document as 'list --format json' (services, apis, apiKeys).
+Functions: upper, lower, join, contains, regexPath, statusIcon, bodyName, responseNames, tsv, json, apiPage, mermaid(topology diagram).
+Built-in list templates can be overridden by placing a file with the same name(api-list.md.tmpl / api-list.tsv.tmpl / api-detail.md.tmpl) in 'templateDir' of the setting file.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := loadApiList()
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: テンプレート出力
+		err = apiList.Render(templateFile, renderOut)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("***command[render] completed.")
+		return nil
+	},
+}
+
+func init() {
+	// INFO:フラグ値を変数にBind
+	renderCmd.Flags().StringVarP(&templateFile, "template", "t", "", "template file path.")
+	renderCmd.Flags().StringVarP(&renderOut, "output", "f", "", "output file path.")
+	renderCmd.MarkFlagRequired("template")
+	renderCmd.MarkFlagRequired("output")
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/teru-0529/api-forge/model"
 )
 
 var (
@@ -20,6 +21,7 @@ var cfgFile string
 var (
 	settingFile string
 	distDir     string
+	filter      model.Filter
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -49,14 +51,48 @@ func init() {
 	// PROCESS:サブコマンドの追加
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(htmlCmd)
 	rootCmd.AddCommand(sqlCmd)
 	rootCmd.AddCommand(fixtureCmd)
+	rootCmd.AddCommand(routeCmd)
+	rootCmd.AddCommand(k8sCmd)
+	rootCmd.AddCommand(nginxCmd)
+	rootCmd.AddCommand(envoyCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(kongCmd)
+	rootCmd.AddCommand(renderCmd)
+	rootCmd.AddCommand(progressCmd)
+	rootCmd.AddCommand(diagramCmd)
+	rootCmd.AddCommand(diffCmd)
 
 	// TODO:cofigファイルの定義(viper)は未整備
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.api-forge.yaml)")
 
 	rootCmd.PersistentFlags().StringVarP(&settingFile, "in", "I", "./api-setup.yaml", "setting file path")
 	rootCmd.PersistentFlags().StringVarP(&distDir, "out", "O", "./dist", "output directry path")
+
+	// INFO:対象の絞り込み(glob形式、複数指定可)
+	rootCmd.PersistentFlags().StringSliceVar(&filter.Services, "service", nil, "filter by service name")
+	rootCmd.PersistentFlags().StringSliceVar(&filter.Tags, "tag", nil, "filter by openapi tag")
+	rootCmd.PersistentFlags().StringSliceVar(&filter.Statuses, "status", nil, "filter by status (prod / mock)")
+	rootCmd.PersistentFlags().StringSliceVar(&filter.Operations, "operation", nil, "filter by operationId")
+}
+
+// FUNCTION: APIファイルの読み込み(絞り込み条件を適用する)
+func loadApiList() (*model.ApiList, error) {
+	apiList, err := model.New(settingFile)
+	if err != nil {
+		return nil, err
+	}
+	return apiList.Filtered(filter)
+}
+
+// FUNCTION: 絞り込み条件に対応しないコマンドのチェック
+func rejectFilter(command string) error {
+	if !filter.IsEmpty() {
+		return fmt.Errorf("command[%s] does not support --service/--tag/--status/--operation", command)
+	}
+	return nil
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -0,0 +1,160 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/teru-0529/api-forge/model"
+)
+
+var (
+	urlFile string
+	headers []string
+)
+
+// routeCmd represents the route command
+var routeCmd = &cobra.Command{
+	Use:   "route",
+	Short: "Inspect generated Kong routes.",
+	Long:  "Inspect generated Kong routes.",
+}
+
+// routeMatchCmd represents the route match command
+var routeMatchCmd = &cobra.Command{
+	Use:   "match [METHOD] [URL]",
+	Short: "Simulate which route matches the request.",
+	Long: `Simulate which route matches the request.
+URL is the path received by the gateway (e.g. /receivoing-orders/receivings/RO-0000001).
+Request headers can be given with --header "Name:Value" (e.g. the mock switching header).
+With --file, each line of the file is evaluated as "METHOD URL [Name:Value ...]".`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if urlFile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// PROCESS: APIファイルの読み込み
+		apiList, err := loadApiList()
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 単一リクエストの評価
+		if urlFile == "" {
+			header, err := parseHeaders(headers)
+			if err != nil {
+				return err
+			}
+			return printMatch(apiList, args[0], args[1], header)
+		}
+
+		// PROCESS: ファイルに記載されたリクエストの評価
+		file, err := os.Open(urlFile)
+		if err != nil {
+			return fmt.Errorf("cannot read file: %w", err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return fmt.Errorf("invalid line (expected 'METHOD URL [Name:Value ...]'): %s", line)
+			}
+			header, err := parseHeaders(append(headers, fields[2:]...))
+			if err != nil {
+				return err
+			}
+			if err := printMatch(apiList, fields[0], fields[1], header); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	},
+}
+
+// FUNCTION: マッチ結果の表示
+func printMatch(apiList *model.ApiList, method string, url string, header map[string]string) error {
+	matched, others, err := apiList.MatchRoutes(method, url, header)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s\n", strings.ToUpper(method), url)
+	if len(matched) == 0 {
+		fmt.Println("  => no route matched (404)")
+
+		// INFO: サービス名のプレフィックスが無い場合のヒント
+		if len(others) == 0 {
+			for _, service := range apiList.Services {
+				hit, _, err := apiList.MatchRoutes(method, "/"+service.ServiceName+url, header)
+				if err != nil {
+					return err
+				}
+				if len(hit) > 0 {
+					fmt.Printf("     hint: did you mean /%s%s ? (%s)\n", service.ServiceName, url, hit[0].ApiKey.OperationId)
+				}
+			}
+		}
+	} else {
+		route := matched[0]
+		fmt.Printf("  => %s(%s)\n", route.ApiKey.Title, route.ApiKey.OperationId)
+		fmt.Printf("     kongId: %s / resourceId: %s\n", route.ApiKey.KongId, route.ApiKey.ResourceId)
+		fmt.Printf("     target: %s\n", route.Target())
+		fmt.Printf("     route : %s ~%s (priority=%d)%s\n", route.Method, route.Regex, route.Priority, headerCondition(route))
+	}
+
+	// PROCESS: その他の候補
+	if len(matched) > 1 || len(others) > 0 {
+		fmt.Println("  candidates:")
+		for _, route := range matched[min(len(matched), 1):] {
+			fmt.Printf("     - %s ~%s (priority=%d)%s %s -> %s\n", route.Method, route.Regex, route.Priority, headerCondition(route), route.ApiKey.OperationId, route.Target())
+		}
+		for _, route := range others {
+			fmt.Printf("     - %s ~%s (priority=%d)%s %s -> %s [method mismatch]\n", route.Method, route.Regex, route.Priority, headerCondition(route), route.ApiKey.OperationId, route.Target())
+		}
+	}
+	return nil
+}
+
+// FUNCTION: Routeのヘッダー条件の表記
+func headerCondition(route model.Route) string {
+	conditions := []string{}
+	for name, value := range route.Headers {
+		conditions = append(conditions, fmt.Sprintf(" [%s: %s]", name, value))
+	}
+	return strings.Join(conditions, "")
+}
+
+// FUNCTION: `Name:Value`形式のヘッダーのパース
+func parseHeaders(items []string) (map[string]string, error) {
+	header := map[string]string{}
+	for _, item := range items {
+		name, value, found := strings.Cut(item, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid header (expected 'Name:Value'): %s", item)
+		}
+		header[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return header, nil
+}
+
+func init() {
+	routeCmd.AddCommand(routeMatchCmd)
+
+	// INFO:フラグ値を変数にBind
+	routeMatchCmd.Flags().StringVarP(&urlFile, "file", "f", "", "file of 'METHOD URL' lines for batch checks.")
+	routeMatchCmd.Flags().StringArrayVarP(&headers, "header", "H", []string{}, "request header as 'Name:Value'.")
+}
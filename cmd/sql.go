@@ -5,28 +5,65 @@ package cmd
 
 import (
 	"fmt"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/teru-0529/api-forge/model"
 )
 
+var (
+	incremental bool
+	aclDialect  string
+	aclSchema   string
+	timestamp   string
+)
+
 // sqlCmd represents the sql command
 var sqlCmd = &cobra.Command{
 	Use:   "sql",
 	Short: "Create Kong and ACL insert data.",
-	Long:  "Create Kong and ACL insert data.",
+	Long: `Create Kong and ACL insert data.
+The ACL data is written in the dialect(postgres/mysql/sqlite) and schema of the 'acl' section of the setting file, or of the flags.
+The operator of the audit columns is taken from --operator, 'audit.operator' of the config file or 'git config user.name' in this order.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		// PROCESS: APIファイルの読み込み
-		apiList, err := model.New(settingFile)
+		apiList, err := loadApiList()
+		if err != nil {
+			return err
+		}
+
+		// PROCESS: 絞り込み時は差分更新のみ許可(全件削除で対象外の行が消えるため)
+		if !filter.IsEmpty() && !incremental {
+			return fmt.Errorf("filtered sql requires --incremental")
+		}
+
+		// PROCESS: 監査情報
+		audit, err := newAudit()
 		if err != nil {
 			return err
 		}
 
 		// PROCESS: SQL出力
-		apiList.Sql4Kong(filepath.Join(distDir, "kongData.sql"))
-		apiList.Sql4Acl(filepath.Join(distDir, "aclData.sql"))
+		err = apiList.Sql4Kong(filepath.Join(distDir, "kongData.sql"), audit, incremental)
+		if err != nil {
+			return err
+		}
+		option := apiList.AclOption()
+		if cmd.Flags().Changed("dialect") {
+			option.Dialect = aclDialect
+		}
+		if cmd.Flags().Changed("acl-schema") {
+			option.Schema = &aclSchema
+		}
+		err = apiList.Sql4Acl(filepath.Join(distDir, "aclData.sql"), option, audit, incremental)
+		if err != nil {
+			return err
+		}
 
 		fmt.Println("***command[sql] completed.")
 		return nil
@@ -34,4 +71,35 @@ var sqlCmd = &cobra.Command{
 }
 
 func init() {
+	// INFO:フラグ値を変数にBind
+	sqlCmd.Flags().BoolVar(&incremental, "incremental", false, "delete/update only the generated rows instead of all rows.")
+	sqlCmd.Flags().StringVar(&aclDialect, "dialect", "postgres", "sql dialect of the acl data (postgres / mysql / sqlite).")
+	sqlCmd.Flags().StringVar(&aclSchema, "acl-schema", "acl", "schema of the acl tables (empty for none).")
+	sqlCmd.Flags().String("operator", "", "operator written to the audit columns.")
+	sqlCmd.Flags().String("ticket", "", "change ticket number written to the audit columns.")
+	sqlCmd.Flags().StringVar(&timestamp, "timestamp", "", "timestamp of the audit columns (RFC3339, default is the db current time).")
+	viper.BindPFlag("audit.operator", sqlCmd.Flags().Lookup("operator"))
+	viper.BindPFlag("audit.ticket", sqlCmd.Flags().Lookup("ticket"))
+}
+
+// FUNCTION: 監査情報の作成(フラグ > configファイル > gitの設定)
+func newAudit() (model.Audit, error) {
+	audit := model.Audit{
+		Version:  version,
+		Operator: viper.GetString("audit.operator"),
+		Ticket:   viper.GetString("audit.ticket"),
+	}
+	if audit.Operator == "" {
+		if out, err := exec.Command("git", "config", "user.name").Output(); err == nil {
+			audit.Operator = strings.TrimSpace(string(out))
+		}
+	}
+	if timestamp != "" {
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return audit, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		audit.Timestamp = &t
+	}
+	return audit, nil
 }
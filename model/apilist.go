@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/google/uuid"
@@ -16,25 +17,59 @@ import (
 
 // TITLE: ApiList構造体
 type ApiList struct {
-	WorkSpaceId string    `yaml:"workSpaceId"`
-	InitIsMock  bool      `yaml:"initIsMock"`
-	Services    []Service `yaml:"services"`
+	WorkSpaceId string      `yaml:"workSpaceId"`
+	Workspaces  []Workspace `yaml:"workspaces,omitempty"`
+	InitIsMock  bool        `yaml:"initIsMock"`
+	MockHeader  *MockHeader `yaml:"mockHeader,omitempty"`
+	// INFO: securityを上書きするAPIでService単位の認証Pluginを無効化するConsumer(id/username)
+	AnonymousConsumer string     `yaml:"anonymousConsumer,omitempty"`
+	Roles             []Role     `yaml:"roles,omitempty"`
+	Acl               *AclOption `yaml:"acl,omitempty"`
+	TemplateDir       string     `yaml:"templateDir,omitempty"`
+	Services          []Service  `yaml:"services"`
+	settingPath       string
+	settingHash       string
+	unfiltered        *ApiList
+}
+
+// INFO: 指定した場合、全APIについてヘッダー付きでMockに転送するRouteを併せて作成する
+type MockHeader struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value,omitempty"`
 }
 
 type Service struct {
-	ServiceName string `yaml:"serviceName"`
-	OpenapiPath string `yaml:"openapiPath"`
+	ServiceName string   `yaml:"serviceName"`
+	OpenapiPath string   `yaml:"openapiPath"`
+	ResourceId  string   `yaml:"resourceId"`
+	Workspaces  []string `yaml:"workspaces,omitempty"`
 	openapi     Openapi
 	ProdServer  Server   `yaml:"prodServer"`
 	MockServer  Server   `yaml:"mockServer"`
+	Tags        []TagKey `yaml:"tags,omitempty"`
 	Apis        []ApiKey `yaml:"apis"`
 }
 
 type Server struct {
-	Host      string `yaml:"host"`
-	Port      int    `yaml:"port"`
-	ServiceId string `yaml:"serviceId"`
+	Host       string   `yaml:"host"`
+	Port       int      `yaml:"port"`
+	ServiceId  string   `yaml:"serviceId"`
+	Targets    []Target `yaml:"targets,omitempty"`
+	K8sService string   `yaml:"k8sService,omitempty"`
+}
+
+// INFO: 指定した場合、Kongのupstream/targetを作成し、serviceはupstreamに転送する
+type Target struct {
+	Target string `yaml:"target"`
+	Weight int    `yaml:"weight,omitempty"`
 }
+
+// INFO: OpenAPIのタグに対応する親リソース
+type TagKey struct {
+	Name       string `yaml:"name"`
+	ResourceId string `yaml:"resourceId"`
+}
+
 type ApiKey struct {
 	Title       string `yaml:"title"`
 	OperationId string `yaml:"operationId"`
@@ -56,6 +91,11 @@ func New(path string) (*ApiList, error) {
 		// PROCESS: serverの設定
 		apiList.Services[i].ProdServer.init()
 		apiList.Services[i].MockServer.init()
+		for _, server := range []Server{service.ProdServer, service.MockServer} {
+			if err := server.checkTargets(); err != nil {
+				return nil, fmt.Errorf("service '%s': %w", service.ServiceName, err)
+			}
+		}
 
 		// PROCESS: openapi読込み
 		openapi, err := NewOpenapi(apiList.Services[i])
@@ -64,6 +104,20 @@ func New(path string) (*ApiList, error) {
 		}
 		apiList.Services[i].openapi = *openapi
 
+		// PROCESS: 親リソース(Service/Tag)設定
+		if service.ResourceId == "" {
+			apiList.Services[i].ResourceId = apiList.newResourceId("SVC", service.ServiceName, 0)
+		}
+		for _, tag := range openapi.tags {
+			if _, err := apiList.Services[i].getTagKey(tag.name); err != nil {
+				tagKey := TagKey{
+					Name:       tag.name,
+					ResourceId: apiList.newResourceId("TAG", service.ServiceName, len(apiList.Services[i].Tags)),
+				}
+				apiList.Services[i].Tags = append(apiList.Services[i].Tags, tagKey)
+			}
+		}
+
 		// PROCESS: APIリスト(不足分)設定
 		for _, item := range openapi.apis {
 			if !service.registered(item.operationId) {
@@ -71,7 +125,7 @@ func New(path string) (*ApiList, error) {
 					Title:       item.summary,
 					OperationId: item.operationId,
 					KongId:      uuid.NewString(),
-					ResourceId:  generateResourceId(service.ServiceName, len(apiList.Services[i].Apis)),
+					ResourceId:  apiList.newResourceId("API", service.ServiceName, len(apiList.Services[i].Apis)),
 					Implemented: !apiList.InitIsMock,
 				}
 				apiList.Services[i].Apis = append(apiList.Services[i].Apis, apiKey)
@@ -79,9 +133,24 @@ func New(path string) (*ApiList, error) {
 		}
 	}
 
+	// PROCESS: リソースIDの重複チェック(設定ファイルで指定したIDの重複)
+	if err := apiList.checkResourceIds(); err != nil {
+		return nil, err
+	}
+
+	// PROCESS: ロール設定のチェック
+	apiList.checkRoles()
+
 	// PROCESS: 設定ファイル保存
 	apiList.Write(path)
 
+	// PROCESS: 設定ファイルのハッシュ(保存後の内容)
+	apiList.settingPath = path
+	apiList.settingHash, err = fileHash(path)
+	if err != nil {
+		return nil, err
+	}
+
 	return apiList, nil
 }
 
@@ -121,6 +190,24 @@ func (service *Service) getApikey(operationId string) (*ApiKey, error) {
 	return nil, errors.New("Not found")
 }
 
+// FUNCTION: TagKeyの取得
+func (service *Service) getTagKey(name string) (*TagKey, error) {
+	for _, tag := range service.Tags {
+		if tag.Name == name {
+			return &tag, nil
+		}
+	}
+	return nil, errors.New("Not found")
+}
+
+// FUNCTION: Mock切替ヘッダーの値(未指定の場合は`true`)
+func (header *MockHeader) value() string {
+	if header.Value == "" {
+		return "true"
+	}
+	return header.Value
+}
+
 // FUNCTION: ServiceIdの設定
 func (server *Server) init() {
 	if server.ServiceId == "" {
@@ -128,8 +215,8 @@ func (server *Server) init() {
 	}
 }
 
-// FUNCTION: リソースID
-func generateResourceId(serviceName string, seq int) string {
+// FUNCTION: リソースID(`API`/`SVC`/`TAG`)
+func generateResourceId(kind string, serviceName string, seq int) string {
 	var name string
 	if len(serviceName) > 6 {
 		name = serviceName[:6]
@@ -137,7 +224,61 @@ func generateResourceId(serviceName string, seq int) string {
 		name = serviceName + strings.Repeat("_", 6-len(serviceName))
 	}
 
-	return fmt.Sprintf("API-%s-%06d", name, seq+1)
+	return fmt.Sprintf("%s-%s-%06d", kind, name, seq+1)
+}
+
+// FUNCTION: 未使用のリソースIDを生成
+// INFO: サービス名の先頭6文字が同じサービスなどで重複しないよう、使用済みの場合は連番を進める
+func (apiList *ApiList) newResourceId(kind string, serviceName string, seq int) string {
+	used := apiList.resourceIds()
+	for {
+		id := generateResourceId(kind, serviceName, seq)
+		if used[id] == 0 {
+			return id
+		}
+		seq++
+	}
+}
+
+// FUNCTION: リソースIDごとの使用数(Service/Tag/API)
+func (apiList *ApiList) resourceIds() map[string]int {
+	ids := map[string]int{}
+	for _, service := range apiList.Services {
+		if service.ResourceId != "" {
+			ids[service.ResourceId]++
+		}
+		for _, tag := range service.Tags {
+			ids[tag.ResourceId]++
+		}
+		for _, api := range service.Apis {
+			ids[api.ResourceId]++
+		}
+	}
+	return ids
+}
+
+// FUNCTION: リソースIDの重複チェック
+func (apiList *ApiList) checkResourceIds() error {
+	duplicates := []string{}
+	for id, count := range apiList.resourceIds() {
+		if count > 1 {
+			duplicates = append(duplicates, id)
+		}
+	}
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		return fmt.Errorf("duplicate resourceId: %s", strings.Join(duplicates, ", "))
+	}
+	return nil
+}
+
+// FUNCTION: 基準となるIDから派生IDを生成(同じ入力に対して常に同じIDとなる)
+func deriveId(baseId string, name string) string {
+	namespace, err := uuid.Parse(baseId)
+	if err != nil {
+		namespace = uuid.NewSHA1(uuid.NameSpaceOID, []byte(baseId))
+	}
+	return uuid.NewSHA1(namespace, []byte(name)).String()
 }
 
 // FUNCTION: ApiList構造のパース
@@ -0,0 +1,39 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// FUNCTION: テスト用のApiList(設定ファイルは書き換えられるため一時フォルダにコピーする)
+func newTestApiList(t *testing.T, name string) *ApiList {
+	t.Helper()
+	source, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "api-setup.yaml")
+	if err := os.WriteFile(path, source, 0666); err != nil {
+		t.Fatal(err)
+	}
+	apiList, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return apiList
+}
+
+// FUNCTION: サービス名の先頭6文字が同じでもリソースIDが重複しないこと
+func TestNewResourceIdPrefixCollision(t *testing.T) {
+	apiList := newTestApiList(t, "api-setup-prefix.yaml")
+	if err := apiList.checkResourceIds(); err != nil {
+		t.Fatal(err)
+	}
+	if first, second := apiList.Services[0].ResourceId, apiList.Services[1].ResourceId; first == second {
+		t.Errorf("service resourceId collides: %s", first)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"time"
+)
+
+// TITLE: Audit構造体(SQLの監査列・ヘッダーに出力する情報)
+type Audit struct {
+	Version   string
+	Operator  string
+	Ticket    string
+	Timestamp *time.Time
+}
+
+// FUNCTION: 作成者/更新者列の値(チケット番号を指定した場合は`operator:ticket`)
+func (audit Audit) traceId() string {
+	operator := audit.Operator
+	if operator == "" {
+		operator = TRACE_ID
+	}
+	if audit.Ticket == "" {
+		return operator
+	}
+	return fmt.Sprintf("%s:%s", operator, audit.Ticket)
+}
+
+// FUNCTION: SQLファイルのヘッダー(ツールのバージョン・入力ファイルのハッシュ)
+func (apiList *ApiList) sqlHeader(audit Audit) string {
+	generatedAt := time.Now()
+	if audit.Timestamp != nil {
+		generatedAt = *audit.Timestamp
+	}
+	header := fmt.Sprintf("-- generator   : api-forge %s\n", audit.Version)
+	header += fmt.Sprintf("-- generatedAt : %s\n", generatedAt.Format(time.RFC3339))
+	header += fmt.Sprintf("-- operator    : %s\n", audit.traceId())
+	header += fmt.Sprintf("-- setting     : %s (sha256:%s)\n", apiList.settingPath, apiList.settingHash)
+	for _, service := range apiList.Services {
+		header += fmt.Sprintf("-- spec        : %s (sha256:%s)\n", service.OpenapiPath, service.openapi.hash)
+	}
+	return header
+}
+
+// FUNCTION: ファイルのハッシュ(sha256)
+func fileHash(path string) (string, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read file: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(file)), nil
+}
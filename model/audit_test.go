@@ -0,0 +1,64 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// FUNCTION: 作成者・更新者・日時の列とヘッダーに監査情報を出力すること
+func TestSql4AclAudit(t *testing.T) {
+	apiList := ApiList{Services: []Service{newTestService(t, "sample", taggedSpec)}}
+	at := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	audit := Audit{Version: "v1.2.3", Operator: "alice", Ticket: "OPS-1", Timestamp: &at}
+
+	// PROCESS: SQL出力
+	schema := ""
+	path := filepath.Join(t.TempDir(), "aclData.sql")
+	if err := apiList.Sql4Acl(path, AclOption{Dialect: "sqlite", Schema: &schema}, audit, false); err != nil {
+		t.Fatal(err)
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"-- generator   : api-forge v1.2.3\n",
+		"-- generatedAt : 2024-04-01T09:00:00Z\n",
+		"-- operator    : alice:OPS-1\n",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("missing header %q", want)
+		}
+	}
+
+	// PROCESS: 適用した行の監査列
+	db, err := OpenDatabase("sqlite://" + filepath.Join(t.TempDir(), "acl.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(aclSchemaSqlite); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplySql(db, path, false); err != nil {
+		t.Fatal(err)
+	}
+	var createdAt, createdBy, updatedBy string
+	if err := db.QueryRow("SELECT created_at, created_by, updated_by FROM resources WHERE resource_id = 'SVC-sample'").Scan(&createdAt, &createdBy, &updatedBy); err != nil {
+		t.Fatal(err)
+	}
+	if createdAt != "2024-04-01 09:00:00" || createdBy != "alice:OPS-1" || updatedBy != "alice:OPS-1" {
+		t.Errorf("audit columns = %s, %s, %s", createdAt, createdBy, updatedBy)
+	}
+
+	// PROCESS: 操作者未指定の場合は既定のID
+	if got := (Audit{Ticket: "OPS-2"}).traceId(); got != TRACE_ID+":OPS-2" {
+		t.Errorf("traceId = %s", got)
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+const (
+	NODE_GATEWAY = "gateway"
+	NODE_PROD    = "prod"
+	NODE_MOCK    = "mock"
+)
+
+// INFO: 実装状況ごとの色(fill, stroke)
+var nodeColors = map[string][2]string{
+	NODE_GATEWAY: {"#e7f1ff", "#0d6efd"},
+	NODE_PROD:    {"#d1e7dd", "#198754"},
+	NODE_MOCK:    {"#fff3cd", "#fd7e14"},
+}
+
+// TITLE: Topology構造体(gateway→Kong service→route→operation)
+type topology struct {
+	groups []topologyGroup
+	nodes  []topologyNode
+	edges  []topologyEdge
+}
+
+type topologyGroup struct {
+	id    string
+	label string
+}
+
+type topologyNode struct {
+	id    string
+	label []string
+	class string
+	group string
+}
+
+type topologyEdge struct {
+	from   string
+	to     string
+	dashed bool
+}
+
+// FUNCTION: Topologyの作成
+func (apiList *ApiList) topology() (*topology, error) {
+	graph := topology{}
+	graph.nodes = append(graph.nodes, topologyNode{id: "gateway", label: []string{"Kong gateway"}, class: NODE_GATEWAY})
+
+	for i, service := range apiList.Services {
+		group := fmt.Sprintf("s%d", i+1)
+		graph.groups = append(graph.groups, topologyGroup{id: group, label: fmt.Sprintf("%s(%s)", service.openapi.description, service.ServiceName)})
+
+		// PROCESS: Kong service(prod/mock)
+		servers := map[string]string{}
+		for _, item := range []struct {
+			server Server
+			class  string
+		}{{service.ProdServer, NODE_PROD}, {service.MockServer, NODE_MOCK}} {
+			id := fmt.Sprintf("%s_%s", group, item.class)
+			servers[item.server.ServiceId] = id
+			graph.nodes = append(graph.nodes, topologyNode{
+				id:    id,
+				label: []string{fmt.Sprintf("%s service", item.class), fmt.Sprintf("%s:%d", item.server.kongHost(service.ServiceName, item.class == NODE_MOCK), item.server.Port)},
+				class: item.class,
+				group: group,
+			})
+			graph.edges = append(graph.edges, topologyEdge{from: "gateway", to: id})
+		}
+
+		// PROCESS: Operation
+		operations := map[string]string{}
+		for j, api := range service.openapi.apis {
+			apiKey, err := service.getApikey(api.operationId)
+			if err != nil {
+				return nil, err
+			}
+			id := fmt.Sprintf("%s_o%d", group, j+1)
+			operations[api.operationId] = id
+			graph.nodes = append(graph.nodes, topologyNode{
+				id:    id,
+				label: []string{api.summary, api.operationId},
+				class: statusClass(apiKey.Implemented),
+				group: group,
+			})
+		}
+
+		// PROCESS: Route
+		// INFO: ヘッダー切替用のRouteは点線で結ぶ
+		routes, err := service.routes(apiList.MockHeader)
+		if err != nil {
+			return nil, err
+		}
+		for j, route := range routes {
+			id := fmt.Sprintf("%s_r%d", group, j+1)
+			label := []string{fmt.Sprintf("%s %s", route.Method, route.Path)}
+			for _, name := range sortedKeys(route.Headers) {
+				label = append(label, fmt.Sprintf("%s: %s", name, route.Headers[name]))
+			}
+			graph.nodes = append(graph.nodes, topologyNode{
+				id:    id,
+				label: label,
+				class: statusClass(!route.IsMock),
+				group: group,
+			})
+			dashed := len(route.Headers) > 0
+			graph.edges = append(graph.edges,
+				topologyEdge{from: servers[route.Server.ServiceId], to: id, dashed: dashed},
+				topologyEdge{from: id, to: operations[route.ApiKey.OperationId], dashed: dashed},
+			)
+		}
+	}
+	return &graph, nil
+}
+
+// FUNCTION: 実装状況のクラス
+func statusClass(implemented bool) string {
+	if implemented {
+		return NODE_PROD
+	}
+	return NODE_MOCK
+}
+
+// FUNCTION: グループ内のノード
+func (graph *topology) members(group string) []topologyNode {
+	nodes := []topologyNode{}
+	for _, node := range graph.nodes {
+		if node.group == group {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// FUNCTION: Mermaid(flowchart)
+func (apiList *ApiList) Mermaid() (string, error) {
+	graph, err := apiList.topology()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for _, node := range graph.members("") {
+		sb.WriteString(fmt.Sprintf("  %s([\"%s\"])\n", node.id, mermaidLabel(node.label)))
+	}
+	for _, group := range graph.groups {
+		sb.WriteString(fmt.Sprintf("  subgraph %s[\"%s\"]\n", group.id, mermaidLabel([]string{group.label})))
+		for _, node := range graph.members(group.id) {
+			sb.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", node.id, mermaidLabel(node.label)))
+		}
+		sb.WriteString("  end\n")
+	}
+	for _, edge := range graph.edges {
+		arrow := "-->"
+		if edge.dashed {
+			arrow = "-.->"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %s\n", edge.from, arrow, edge.to))
+	}
+
+	// PROCESS: 色(クラス)
+	classes := map[string][]string{}
+	for _, node := range graph.nodes {
+		classes[node.class] = append(classes[node.class], node.id)
+	}
+	for _, class := range sortedKeys(classes) {
+		sb.WriteString(fmt.Sprintf("  classDef %s fill:%s,stroke:%s\n", class, nodeColors[class][0], nodeColors[class][1]))
+		sb.WriteString(fmt.Sprintf("  class %s %s\n", strings.Join(classes[class], ","), class))
+	}
+	return sb.String(), nil
+}
+
+// FUNCTION: Mermaidのラベル(ダブルクォートはエンティティに置き換える)
+func mermaidLabel(lines []string) string {
+	escaped := []string{}
+	for _, line := range lines {
+		escaped = append(escaped, strings.ReplaceAll(line, `"`, "#quot;"))
+	}
+	return strings.Join(escaped, "<br/>")
+}
+
+// FUNCTION: DOT(Graphviz)
+func (apiList *ApiList) Dot() (string, error) {
+	graph, err := apiList.topology()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("digraph topology {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"sans-serif\"];\n")
+	for _, node := range graph.members("") {
+		sb.WriteString(fmt.Sprintf("  %s %s;\n", node.id, dotAttributes(node)))
+	}
+	for _, group := range graph.groups {
+		sb.WriteString(fmt.Sprintf("  subgraph cluster_%s {\n", group.id))
+		sb.WriteString(fmt.Sprintf("    label=%s;\n", dotString(group.label)))
+		for _, node := range graph.members(group.id) {
+			sb.WriteString(fmt.Sprintf("    %s %s;\n", node.id, dotAttributes(node)))
+		}
+		sb.WriteString("  }\n")
+	}
+	for _, edge := range graph.edges {
+		style := ""
+		if edge.dashed {
+			style = " [style=dashed]"
+		}
+		sb.WriteString(fmt.Sprintf("  %s -> %s%s;\n", edge.from, edge.to, style))
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// FUNCTION: DOTのノード属性
+func dotAttributes(node topologyNode) string {
+	return fmt.Sprintf("[label=%s, fillcolor=%s, color=%s]",
+		dotString(strings.Join(node.label, "\n")),
+		dotString(nodeColors[node.class][0]),
+		dotString(nodeColors[node.class][1]),
+	)
+}
+
+// FUNCTION: DOTの文字列リテラル
+func dotString(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// FUNCTION: Mermaidファイルの書き込み
+func (apiList *ApiList) DiagramMermaid(path string) error {
+	return apiList.writeDiagram(path, apiList.Mermaid)
+}
+
+// FUNCTION: DOTファイルの書き込み
+func (apiList *ApiList) DiagramDot(path string) error {
+	return apiList.writeDiagram(path, apiList.Dot)
+}
+
+// FUNCTION: ダイアグラムの書き込み(共通)
+func (apiList *ApiList) writeDiagram(path string, source func() (string, error)) error {
+	diagram, err := source()
+	if err != nil {
+		return err
+	}
+
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	_, err = file.WriteString(diagram)
+	return err
+}
+
+// FUNCTION: MDファイルへのMermaidの追記
+func (apiList *ApiList) AppendMermaid(path string) error {
+	diagram, err := apiList.Mermaid()
+	if err != nil {
+		return err
+	}
+
+	// PROCESS: Fileの取得(追記)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return fmt.Errorf("cannot open file: %w", err)
+	}
+	defer file.Close()
+
+	// PROCESS: 書き込み
+	_, err = file.WriteString(fmt.Sprintf("\n## Topology\n\n```mermaid\n%s```\n", diagram))
+	return err
+}
@@ -0,0 +1,52 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+// FUNCTION: 転送先のServiceからRoute・オペレーションへの経路を出力すること(ヘッダー切替は点線)
+func TestTopologyDiagrams(t *testing.T) {
+	spec := strings.Replace(samePathSpec, "      operationId: receivings.post\n", "      operationId: receivings.post\n      summary: 入荷\"登録\"\n", 1)
+	apiList := ApiList{
+		MockHeader: &MockHeader{Name: "X-Mock"},
+		Services:   []Service{newTestService(t, "orders", spec, "receivings.post")},
+	}
+
+	mermaid, err := apiList.Mermaid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"flowchart LR\n",
+		"  gateway --> s1_prod\n",
+		"  gateway --> s1_mock\n",
+		"    s1_prod[\"prod service<br/>orders:8080\"]\n",
+		"    s1_o2[\"入荷#quot;登録#quot;<br/>receivings.post\"]\n",
+		"  s1_prod --> s1_r3\n",
+		"  s1_mock -.-> s1_r4\n",
+		"  s1_r4 -.-> s1_o2\n",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("mermaid: missing %q in\n%s", want, mermaid)
+		}
+	}
+
+	dot, err := apiList.Dot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"  subgraph cluster_s1 {\n",
+		`    s1_o2 [label="入荷\"登録\"\nreceivings.post", fillcolor="#d1e7dd", color="#198754"];` + "\n",
+		"  s1_mock -> s1_r4 [style=dashed];\n",
+		"  s1_prod -> s1_r3;\n",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("dot: missing %q in\n%s", want, dot)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TITLE: AclOption構造体(api-setup.yamlの`acl`、コマンドのフラグで上書き可)
+type AclOption struct {
+	Dialect string    `yaml:"dialect,omitempty"`
+	Schema  *string   `yaml:"schema,omitempty"`
+	Tables  AclTables `yaml:"tables,omitempty"`
+}
+
+type AclTables struct {
+	Resources         string `yaml:"resources,omitempty"`
+	ApiResources      string `yaml:"apiResources,omitempty"`
+	ResourceRelations string `yaml:"resourceRelations,omitempty"`
+	RoleResources     string `yaml:"roleResources,omitempty"`
+}
+
+// TITLE: SQLの方言
+type Dialect interface {
+	// テーブル名(スキーマ修飾・クォート済み)
+	table(schema string, name string) string
+	// 現在日時
+	now() string
+	// 日時リテラル
+	timestamp(t time.Time) string
+	// 文字列リテラル
+	literal(value string) string
+	// 重複時に指定列を更新するINSERTの後置句
+	upsert(key string, columns []string) string
+}
+
+type postgres struct{}
+type mysql struct{}
+type sqlite struct{}
+
+// クォートが不要な識別子
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// FUNCTION: 方言の取得
+func NewDialect(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "", "postgres", "postgresql":
+		return postgres{}, nil
+	case "mysql", "mariadb":
+		return mysql{}, nil
+	case "sqlite", "sqlite3":
+		return sqlite{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", name)
+	}
+}
+
+// FUNCTION: 既定値を補完したAclOption
+func (apiList *ApiList) AclOption() AclOption {
+	option := AclOption{}
+	if apiList.Acl != nil {
+		option = *apiList.Acl
+	}
+	if option.Dialect == "" {
+		option.Dialect = "postgres"
+	}
+	if option.Schema == nil {
+		schema := "acl"
+		option.Schema = &schema
+	}
+	option.Tables = option.Tables.withDefaults()
+	return option
+}
+
+// FUNCTION: テーブル名の既定値
+func (tables AclTables) withDefaults() AclTables {
+	if tables.Resources == "" {
+		tables.Resources = "resources"
+	}
+	if tables.ApiResources == "" {
+		tables.ApiResources = "api_resources"
+	}
+	if tables.ResourceRelations == "" {
+		tables.ResourceRelations = "resource_relations"
+	}
+	if tables.RoleResources == "" {
+		tables.RoleResources = "role_resources"
+	}
+	return tables
+}
+
+// FUNCTION: postgres
+func (postgres) table(schema string, name string) string {
+	return qualify(schema, name, `"`)
+}
+
+func (postgres) now() string {
+	return "CURRENT_TIMESTAMP"
+}
+
+func (postgres) timestamp(t time.Time) string {
+	return fmt.Sprintf("'%s'", t.Format("2006-01-02 15:04:05.000000-07:00"))
+}
+
+func (postgres) literal(value string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", "''"))
+}
+
+func (postgres) upsert(key string, columns []string) string {
+	return onConflict(key, columns)
+}
+
+// FUNCTION: mysql(スキーマはデータベースとして扱う)
+func (mysql) table(schema string, name string) string {
+	return qualify(schema, name, "`")
+}
+
+func (mysql) now() string {
+	return "CURRENT_TIMESTAMP(6)"
+}
+
+// INFO: タイムゾーンを持たないためUTCで出力する
+func (mysql) timestamp(t time.Time) string {
+	return fmt.Sprintf("'%s'", t.UTC().Format("2006-01-02 15:04:05.000000"))
+}
+
+// INFO: 既定のsql_modeではバックスラッシュもエスケープ文字として扱われる
+func (mysql) literal(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", "''"))
+}
+
+func (mysql) upsert(key string, columns []string) string {
+	sets := []string{}
+	for _, column := range columns {
+		sets = append(sets, fmt.Sprintf("%s = VALUES(%s)", column, column))
+	}
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+// FUNCTION: sqlite(スキーマはATTACHしたデータベース名として扱う)
+func (sqlite) table(schema string, name string) string {
+	return qualify(schema, name, `"`)
+}
+
+func (sqlite) now() string {
+	return "CURRENT_TIMESTAMP"
+}
+
+// INFO: CURRENT_TIMESTAMPと同じくUTCで出力する
+func (sqlite) timestamp(t time.Time) string {
+	return fmt.Sprintf("'%s'", t.UTC().Format("2006-01-02 15:04:05"))
+}
+
+func (sqlite) literal(value string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", "''"))
+}
+
+func (sqlite) upsert(key string, columns []string) string {
+	return onConflict(key, columns)
+}
+
+// FUNCTION: ON CONFLICT句(postgres/sqlite)
+func onConflict(key string, columns []string) string {
+	sets := []string{}
+	for _, column := range columns {
+		sets = append(sets, fmt.Sprintf("%s = excluded.%s", column, column))
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", key, strings.Join(sets, ", "))
+}
+
+// FUNCTION: スキーマ修飾(スキーマ未指定の場合はテーブル名のみ)
+func qualify(schema string, name string, quote string) string {
+	if schema == "" {
+		return quoteIdentifier(name, quote)
+	}
+	return quoteIdentifier(schema, quote) + "." + quoteIdentifier(name, quote)
+}
+
+// FUNCTION: 識別子のクォート(必要な場合のみ)
+func quoteIdentifier(name string, quote string) string {
+	if plainIdentifier.MatchString(name) {
+		return name
+	}
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
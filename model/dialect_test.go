@@ -0,0 +1,53 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// FUNCTION: 方言毎のテーブル名・リテラル・日時・UPSERT
+func TestDialects(t *testing.T) {
+	at := time.Date(2024, 4, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	for _, tt := range []struct {
+		name      string
+		table     string
+		literal   string
+		timestamp string
+		upsert    string
+	}{
+		{"postgresql", `"Auth".resources`, `'it''s\'`, `'2024-04-01 09:00:00.000000+09:00'`,
+			" ON CONFLICT (resource_id) DO UPDATE SET resource_name = excluded.resource_name"},
+		{"mariadb", "`Auth`.resources", `'it''s\\'`, `'2024-04-01 00:00:00.000000'`,
+			" ON DUPLICATE KEY UPDATE resource_name = VALUES(resource_name)"},
+		{"sqlite3", `"Auth".resources`, `'it''s\'`, `'2024-04-01 00:00:00'`,
+			" ON CONFLICT (resource_id) DO UPDATE SET resource_name = excluded.resource_name"},
+	} {
+		dialect, err := NewDialect(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dialect.table("Auth", "resources"); got != tt.table {
+			t.Errorf("%s: table = %s, want %s", tt.name, got, tt.table)
+		}
+		if got := dialect.literal(`it's\`); got != tt.literal {
+			t.Errorf("%s: literal = %s, want %s", tt.name, got, tt.literal)
+		}
+		if got := dialect.timestamp(at); got != tt.timestamp {
+			t.Errorf("%s: timestamp = %s, want %s", tt.name, got, tt.timestamp)
+		}
+		if got := dialect.upsert("resource_id", []string{"resource_name"}); got != tt.upsert {
+			t.Errorf("%s: upsert = %s, want %s", tt.name, got, tt.upsert)
+		}
+	}
+
+	// PROCESS: スキーマ未指定・未対応の方言
+	if got := (postgres{}).table("", "role-resources"); got != `"role-resources"` {
+		t.Errorf("table = %s", got)
+	}
+	if _, err := NewDialect("oracle"); err == nil {
+		t.Error("unsupported dialect must be an error")
+	}
+}
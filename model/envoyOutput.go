@@ -0,0 +1,233 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+// RouteConfiguration名称
+const ENVOY_ROUTE_CONFIG = "api-forge"
+
+// TITLE: Envoy設定構造体(ファイルベースのxDSリソース)
+type envoyRouteResources struct {
+	Resources []envoyRouteConfiguration `yaml:"resources"`
+}
+
+type envoyRouteConfiguration struct {
+	Type         string             `yaml:"@type"`
+	Name         string             `yaml:"name"`
+	VirtualHosts []envoyVirtualHost `yaml:"virtual_hosts"`
+}
+
+type envoyVirtualHost struct {
+	Name    string       `yaml:"name"`
+	Domains []string     `yaml:"domains"`
+	Routes  []envoyRoute `yaml:"routes"`
+}
+
+type envoyRoute struct {
+	Name  string           `yaml:"name"`
+	Match envoyRouteMatch  `yaml:"match"`
+	Route envoyRouteAction `yaml:"route"`
+}
+
+type envoyRouteMatch struct {
+	SafeRegex envoyRegex           `yaml:"safe_regex"`
+	Headers   []envoyHeaderMatcher `yaml:"headers"`
+}
+
+type envoyRegex struct {
+	Regex string `yaml:"regex"`
+}
+
+type envoyHeaderMatcher struct {
+	Name        string             `yaml:"name"`
+	StringMatch envoyStringMatcher `yaml:"string_match"`
+}
+
+type envoyStringMatcher struct {
+	Exact      string `yaml:"exact"`
+	IgnoreCase bool   `yaml:"ignore_case,omitempty"`
+}
+
+type envoyRouteAction struct {
+	Cluster string `yaml:"cluster"`
+}
+
+type envoyClusterResources struct {
+	Resources []envoyCluster `yaml:"resources"`
+}
+
+type envoyCluster struct {
+	Type           string              `yaml:"@type"`
+	Name           string              `yaml:"name"`
+	DiscoveryType  string              `yaml:"type"`
+	ConnectTimeout string              `yaml:"connect_timeout"`
+	LbPolicy       string              `yaml:"lb_policy"`
+	LoadAssignment envoyLoadAssignment `yaml:"load_assignment"`
+	HealthChecks   []envoyHealthCheck  `yaml:"health_checks,omitempty"`
+}
+
+type envoyLoadAssignment struct {
+	ClusterName string               `yaml:"cluster_name"`
+	Endpoints   []envoyLocalityLbEps `yaml:"endpoints"`
+}
+
+type envoyLocalityLbEps struct {
+	LbEndpoints []envoyLbEndpoint `yaml:"lb_endpoints"`
+}
+
+type envoyLbEndpoint struct {
+	Endpoint            envoyEndpoint `yaml:"endpoint"`
+	LoadBalancingWeight int           `yaml:"load_balancing_weight,omitempty"`
+}
+
+type envoyEndpoint struct {
+	Address envoyAddress `yaml:"address"`
+}
+
+type envoyAddress struct {
+	SocketAddress envoySocketAddress `yaml:"socket_address"`
+}
+
+type envoySocketAddress struct {
+	Address   string `yaml:"address"`
+	PortValue int    `yaml:"port_value"`
+}
+
+type envoyHealthCheck struct {
+	Timeout            string               `yaml:"timeout"`
+	Interval           string               `yaml:"interval"`
+	UnhealthyThreshold int                  `yaml:"unhealthy_threshold"`
+	HealthyThreshold   int                  `yaml:"healthy_threshold"`
+	HttpHealthCheck    envoyHttpHealthCheck `yaml:"http_health_check"`
+}
+
+type envoyHttpHealthCheck struct {
+	Path string `yaml:"path"`
+}
+
+// FUNCTION: Envoy設定の書き込み(RDS/CDS)
+func (apiList *ApiList) Envoy(dir string) error {
+	apiList.warnTrafficIgnored("envoy")
+
+	// PROCESS: RouteConfiguration
+	// INFO: Envoyは記載順に評価するため、優先度の高い順に出力する
+	routes, err := apiList.Routes()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Priority > routes[j].Priority
+	})
+
+	virtualHost := envoyVirtualHost{Name: ENVOY_ROUTE_CONFIG, Domains: []string{"*"}, Routes: []envoyRoute{}}
+	for _, route := range routes {
+		virtualHost.Routes = append(virtualHost.Routes, route.envoyRoute())
+	}
+	rds := envoyRouteResources{Resources: []envoyRouteConfiguration{{
+		Type:         "type.googleapis.com/envoy.config.route.v3.RouteConfiguration",
+		Name:         ENVOY_ROUTE_CONFIG,
+		VirtualHosts: []envoyVirtualHost{virtualHost},
+	}}}
+	if err := writeYaml(filepath.Join(dir, "rds.yaml"), &rds); err != nil {
+		return err
+	}
+
+	// PROCESS: Cluster
+	cds := envoyClusterResources{Resources: []envoyCluster{}}
+	for _, service := range apiList.Services {
+		healthPath := service.healthPath()
+		for i, server := range []Server{service.ProdServer, service.MockServer} {
+			cluster, err := server.envoyCluster(envoyClusterName(service.ServiceName, i == 1), healthPath)
+			if err != nil {
+				return err
+			}
+			cds.Resources = append(cds.Resources, cluster)
+		}
+	}
+	return writeYaml(filepath.Join(dir, "cds.yaml"), &cds)
+}
+
+// FUNCTION: Route(名称はKongIdとし、SQL出力と対応付ける)
+func (route Route) envoyRoute() envoyRoute {
+	headers := []envoyHeaderMatcher{{Name: ":method", StringMatch: envoyStringMatcher{Exact: route.Method}}}
+	for _, name := range sortedHeaderNames(route.Headers) {
+		headers = append(headers, envoyHeaderMatcher{Name: name, StringMatch: envoyStringMatcher{Exact: route.Headers[name], IgnoreCase: true}})
+	}
+	return envoyRoute{
+		Name: route.Id,
+		Match: envoyRouteMatch{
+			SafeRegex: envoyRegex{Regex: route.Regex},
+			Headers:   headers,
+		},
+		Route: envoyRouteAction{Cluster: envoyClusterName(route.ServiceName, route.IsMock)},
+	}
+}
+
+// FUNCTION: Cluster
+func (server Server) envoyCluster(name string, healthPath string) (envoyCluster, error) {
+	endpoints := []envoyLbEndpoint{}
+	if len(server.Targets) == 0 {
+		endpoints = append(endpoints, envoyLbEndpoint{Endpoint: newEnvoyEndpoint(server.Host, server.Port)})
+	}
+	for _, target := range server.Targets {
+		host, port, err := target.hostPort()
+		if err != nil {
+			return envoyCluster{}, err
+		}
+		endpoints = append(endpoints, envoyLbEndpoint{Endpoint: newEnvoyEndpoint(host, port), LoadBalancingWeight: target.weight()})
+	}
+
+	cluster := envoyCluster{
+		Type:           "type.googleapis.com/envoy.config.cluster.v3.Cluster",
+		Name:           name,
+		DiscoveryType:  "STRICT_DNS",
+		ConnectTimeout: "5s",
+		LbPolicy:       "ROUND_ROBIN",
+		LoadAssignment: envoyLoadAssignment{
+			ClusterName: name,
+			Endpoints:   []envoyLocalityLbEps{{LbEndpoints: endpoints}},
+		},
+	}
+	if healthPath != "" {
+		cluster.HealthChecks = []envoyHealthCheck{{
+			Timeout:            "1s",
+			Interval:           "5s",
+			UnhealthyThreshold: 3,
+			HealthyThreshold:   2,
+			HttpHealthCheck:    envoyHttpHealthCheck{Path: healthPath},
+		}}
+	}
+	return cluster, nil
+}
+
+// FUNCTION: Endpoint
+func newEnvoyEndpoint(host string, port int) envoyEndpoint {
+	return envoyEndpoint{Address: envoyAddress{SocketAddress: envoySocketAddress{Address: host, PortValue: port}}}
+}
+
+// FUNCTION: Cluster名称
+func envoyClusterName(serviceName string, isMock bool) string {
+	if isMock {
+		return fmt.Sprintf("%s_mock", serviceName)
+	}
+	return fmt.Sprintf("%s_prod", serviceName)
+}
+
+// FUNCTION: yamlファイルの書き込み
+func writeYaml(path string, value interface{}) error {
+	// PROCESS: Encoderの取得
+	encoder, cleanup, err := store.NewYamlEncorder(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return encoder.Encode(value)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"testing"
+)
+
+// FUNCTION: Routeのメソッド・ヘッダー条件と転送先のCluster
+func TestEnvoyRoute(t *testing.T) {
+	service := newTestService(t, "orders", samePathSpec, "receivings.post")
+	routes, err := service.routes(&MockHeader{Name: "X-Mock", Value: "on"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clusters := map[string]string{}
+	for _, route := range routes {
+		envoy := route.envoyRoute()
+		if envoy.Name != route.Id || envoy.Match.SafeRegex.Regex != "/orders/receivings" || envoy.Match.Headers[0].StringMatch.Exact != route.Method {
+			t.Errorf("route = %+v", envoy)
+		}
+		key := route.ApiKey.OperationId
+		if len(envoy.Match.Headers) > 1 {
+			header := envoy.Match.Headers[1]
+			if header.Name != "X-Mock" || header.StringMatch.Exact != "on" || !header.StringMatch.IgnoreCase {
+				t.Errorf("header = %+v", header)
+			}
+			key += "+header"
+		}
+		clusters[key] = envoy.Route.Cluster
+	}
+	for key, want := range map[string]string{
+		"receivings.get":         "orders_mock",
+		"receivings.post":        "orders_prod",
+		"receivings.get+header":  "orders_mock",
+		"receivings.post+header": "orders_mock",
+	} {
+		if clusters[key] != want {
+			t.Errorf("%s: cluster = %s, want %s", key, clusters[key], want)
+		}
+	}
+}
+
+// FUNCTION: targetsの重み・ヘルスチェックをClusterに反映すること
+func TestEnvoyCluster(t *testing.T) {
+	server := Server{Targets: []Target{{Target: "[::1]:8080", Weight: 10}, {Target: "orders-2"}}}
+	cluster, err := server.envoyCluster("orders_prod", "/orders/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints := cluster.LoadAssignment.Endpoints[0].LbEndpoints
+	if len(endpoints) != 2 {
+		t.Fatalf("endpoints = %d, want 2", len(endpoints))
+	}
+	if address := endpoints[0].Endpoint.Address.SocketAddress; address.Address != "::1" || address.PortValue != 8080 || endpoints[0].LoadBalancingWeight != 10 {
+		t.Errorf("endpoint[0] = %+v weight=%d", address, endpoints[0].LoadBalancingWeight)
+	}
+	if address := endpoints[1].Endpoint.Address.SocketAddress; address.PortValue != 80 || endpoints[1].LoadBalancingWeight != DEFAULT_WEIGHT {
+		t.Errorf("endpoint[1] = %+v weight=%d", address, endpoints[1].LoadBalancingWeight)
+	}
+	if len(cluster.HealthChecks) != 1 || cluster.HealthChecks[0].HttpHealthCheck.Path != "/orders/health" {
+		t.Errorf("health checks = %+v", cluster.HealthChecks)
+	}
+
+	// PROCESS: 不正なtarget
+	server.Targets = append(server.Targets, Target{Target: "orders-3:http"})
+	if _, err := server.envoyCluster("orders_prod", ""); err == nil {
+		t.Error("invalid target must be an error")
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// TITLE: Filter構造体(service/tag/status/operationIdのパターン)
+// INFO: 同じ種類のパターンはいずれかに一致(OR)、種類間はすべてに一致(AND)した場合に対象とする
+type Filter struct {
+	Services   []string
+	Tags       []string
+	Statuses   []string
+	Operations []string
+}
+
+// FUNCTION: 条件の有無
+func (filter Filter) IsEmpty() bool {
+	return len(filter.Services) == 0 && len(filter.Tags) == 0 && len(filter.Statuses) == 0 && len(filter.Operations) == 0
+}
+
+// FUNCTION: 条件に一致するService/APIのみのApiList
+// INFO: 設定ファイルを保存する場合に備え、元のApiListは変更しない
+func (apiList *ApiList) Filtered(filter Filter) (*ApiList, error) {
+	if err := filter.normalize(); err != nil {
+		return nil, err
+	}
+	filtered := *apiList
+	filtered.unfiltered = apiList.all()
+	filtered.Services = []Service{}
+	if filter.IsEmpty() {
+		filtered.Services = append(filtered.Services, apiList.Services...)
+		return &filtered, nil
+	}
+
+	for _, service := range apiList.Services {
+		if !matchAny(filter.Services, service.ServiceName) {
+			continue
+		}
+		apis := []Api{}
+		for _, api := range service.openapi.apis {
+			apiKey, err := service.getApikey(api.operationId)
+			if err != nil {
+				return nil, err
+			}
+			status := "mock"
+			if apiKey.Implemented {
+				status = "prod"
+			}
+			if matchAny(filter.Operations, api.operationId) && matchAny(filter.Statuses, status) && matchAnyOf(filter.Tags, api.tags) {
+				apis = append(apis, api)
+			}
+		}
+		if len(apis) == 0 {
+			continue
+		}
+		service.openapi.apis = apis
+		filtered.Services = append(filtered.Services, service)
+	}
+	return &filtered, nil
+}
+
+// FUNCTION: 絞り込み前のApiList
+func (apiList *ApiList) all() *ApiList {
+	if apiList.unfiltered != nil {
+		return apiList.unfiltered
+	}
+	return apiList
+}
+
+// FUNCTION: パターンのチェック・正規化
+func (filter Filter) normalize() error {
+	for _, patterns := range [][]string{filter.Services, filter.Tags, filter.Statuses, filter.Operations} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid filter pattern '%s': %w", pattern, err)
+			}
+		}
+	}
+	for i, status := range filter.Statuses {
+		// INFO: `production`は`prod`と同じ扱い
+		if strings.EqualFold(status, "production") {
+			filter.Statuses[i] = "prod"
+		}
+	}
+	return nil
+}
+
+// FUNCTION: いずれかのパターンに一致するか(パターン未指定の場合は一致)
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FUNCTION: いずれかの値がいずれかのパターンに一致するか(パターン未指定の場合は一致)
+func matchAnyOf(patterns []string, values []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if matchAny(patterns, value) {
+			return true
+		}
+	}
+	return false
+}
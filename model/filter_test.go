@@ -0,0 +1,51 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+// FUNCTION: 同じ種類の条件はOR、種類間はANDで絞り込むこと
+func TestFiltered(t *testing.T) {
+	apiList := ApiList{Services: []Service{
+		newTestService(t, "sample", taggedSpec, "items.get"),
+		newTestService(t, "orders", samePathSpec, "receivings.post"),
+	}}
+	for _, tt := range []struct {
+		filter Filter
+		want   []string
+	}{
+		{Filter{}, []string{"items.get", "items.post", "receivings.get", "receivings.post"}},
+		{Filter{Services: []string{"ord*"}}, []string{"receivings.get", "receivings.post"}},
+		{Filter{Statuses: []string{"Production"}}, []string{"items.get", "receivings.post"}},
+		{Filter{Tags: []string{"item"}, Statuses: []string{"mock"}}, []string{"items.post"}},
+		{Filter{Operations: []string{"*.post", "items.get"}}, []string{"items.get", "items.post", "receivings.post"}},
+		{Filter{Services: []string{"none"}}, []string{}},
+	} {
+		filtered, err := apiList.Filtered(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		operations := []string{}
+		for _, service := range filtered.Services {
+			for _, api := range service.openapi.apis {
+				operations = append(operations, api.operationId)
+			}
+		}
+		if !slices.Equal(operations, tt.want) {
+			t.Errorf("%+v: operations = %v, want %v", tt.filter, operations, tt.want)
+		}
+		// INFO: 絞り込み前のApiListは変更しない
+		if len(apiList.Services[0].openapi.apis) != 2 || filtered.all() != &apiList {
+			t.Errorf("%+v: unfiltered apiList changed", tt.filter)
+		}
+	}
+
+	// PROCESS: 不正なパターン
+	if _, err := apiList.Filtered(Filter{Tags: []string{"["}}); err == nil {
+		t.Error("invalid pattern must be an error")
+	}
+}
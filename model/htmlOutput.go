@@ -0,0 +1,43 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"embed"
+	"html/template"
+	"strings"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// FUNCTION: HTMLカタログの書き込み(外部アセットを使わない単一ファイル)
+func (apiList *ApiList) CatalogHtml(path string) error {
+	// PROCESS: テンプレートの読込み
+	tmpl, err := template.New("catalog.html").Funcs(template.FuncMap{
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	}).ParseFS(templates, "templates/catalog.html")
+	if err != nil {
+		return err
+	}
+
+	// PROCESS: 表示用データの作成
+	view, err := apiList.Document()
+	if err != nil {
+		return err
+	}
+
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	return tmpl.Execute(file, view)
+}
@@ -0,0 +1,37 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// FUNCTION: 全APIを外部アセット無しの単一ファイルに出力し、説明をエスケープすること
+func TestCatalogHtml(t *testing.T) {
+	spec := strings.Replace(taggedSpec, "summary: 商品登録", "summary: 商品登録<script>", 1)
+	apiList := ApiList{Services: []Service{newTestService(t, "sample", spec, "items.get")}}
+	path := filepath.Join(t.TempDir(), "api-catalog.html")
+	if err := apiList.CatalogHtml(path); err != nil {
+		t.Fatal(err)
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(source)
+
+	for _, want := range []string{"items.get", "items.post", `<td class="path">/items</td>`, "商品登録&lt;script&gt;", `data-status="prod"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"商品登録<script>", `src="http`, `href="http`} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("unexpected %q", unwanted)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+// INFO: 互換性の無い変更をする場合はメジャーバージョンを上げる(schema/api-list.schema.jsonと合わせる)
+const (
+	DOCUMENT_VERSION = "1.0.0"
+	DOCUMENT_SCHEMA  = "https://raw.githubusercontent.com/teru-0529/api-forge/main/schema/api-list.schema.json"
+)
+
+// TITLE: ApiListDocument構造体(JSON出力用、外部ツールとの互換性を保つ公開モデル)
+type ApiListDocument struct {
+	Schema      string            `json:"$schema"`
+	Version     string            `json:"version"`
+	WorkspaceId string            `json:"workspaceId"`
+	Roles       []string          `json:"roles"`
+	Services    []ServiceDocument `json:"services"`
+}
+
+type ServiceDocument struct {
+	Name        string         `json:"name"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Version     string         `json:"version"`
+	OpenapiPath string         `json:"openapiPath"`
+	ResourceId  string         `json:"resourceId"`
+	Workspaces  []string       `json:"workspaces"`
+	ProdServer  ServerDocument `json:"prodServer"`
+	MockServer  ServerDocument `json:"mockServer"`
+	Tags        []TagDocument  `json:"tags"`
+	Apis        []ApiDocument  `json:"apis"`
+}
+
+type ServerDocument struct {
+	Host      string           `json:"host"`
+	Port      int              `json:"port"`
+	ServiceId string           `json:"serviceId"`
+	Targets   []TargetDocument `json:"targets"`
+}
+
+type TargetDocument struct {
+	Target string `json:"target"`
+	Weight int    `json:"weight"`
+}
+
+type TagDocument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ResourceId  string `json:"resourceId"`
+}
+
+type ApiDocument struct {
+	OperationId string             `json:"operationId"`
+	Method      string             `json:"method"`
+	Path        string             `json:"path"`
+	Summary     string             `json:"summary"`
+	Description string             `json:"description"`
+	Status      string             `json:"status"`
+	ApiKey      ApiKeyDocument     `json:"apiKey"`
+	Tags        []TagDocument      `json:"tags"`
+	Roles       []string           `json:"roles"`
+	RateLimit   string             `json:"rateLimit"`
+	Request     RequestDocument    `json:"request"`
+	Responses   []ResponseDocument `json:"responses"`
+}
+
+type ApiKeyDocument struct {
+	KongId      string `json:"kongId"`
+	ResourceId  string `json:"resourceId"`
+	Implemented bool   `json:"implemented"`
+}
+
+type RequestDocument struct {
+	Parameters []ParameterDocument `json:"parameters"`
+	Body       *BodyDocument       `json:"body"`
+}
+
+type ParameterDocument struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Required    bool        `json:"required"`
+	Description string      `json:"description"`
+	Schema      string      `json:"schema"`
+	Type        string      `json:"type"`
+	Example     interface{} `json:"example,omitempty"`
+}
+
+type BodyDocument struct {
+	Description string          `json:"description"`
+	Required    bool            `json:"required"`
+	ContentType string          `json:"contentType"`
+	Schema      string          `json:"schema"`
+	Fields      []FieldDocument `json:"fields"`
+	Example     interface{}     `json:"example,omitempty"`
+}
+
+type ResponseDocument struct {
+	Status      string           `json:"status"`
+	Description string           `json:"description"`
+	ContentType string           `json:"contentType"`
+	Schema      string           `json:"schema"`
+	Headers     []HeaderDocument `json:"headers"`
+	Fields      []FieldDocument  `json:"fields"`
+	Example     interface{}      `json:"example,omitempty"`
+}
+
+type HeaderDocument struct {
+	Name        string      `json:"name"`
+	Required    bool        `json:"required"`
+	Description string      `json:"description"`
+	Type        string      `json:"type"`
+	Example     interface{} `json:"example,omitempty"`
+}
+
+type FieldDocument struct {
+	Name        string `json:"name"`
+	Required    bool   `json:"required"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+}
+
+// FUNCTION: JSONファイルの書き込み
+func (apiList *ApiList) ListJson(path string) error {
+	// PROCESS: 出力用データの作成
+	document, err := apiList.Document()
+	if err != nil {
+		return err
+	}
+
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(document)
+}
+
+// FUNCTION: 公開モデルへの変換
+func (apiList *ApiList) Document() (*ApiListDocument, error) {
+	document := ApiListDocument{
+		Schema:      DOCUMENT_SCHEMA,
+		Version:     DOCUMENT_VERSION,
+		WorkspaceId: apiList.WorkSpaceId,
+		Roles:       apiList.roleNames(),
+		Services:    []ServiceDocument{},
+	}
+	for _, service := range apiList.Services {
+		item := ServiceDocument{
+			Name:        service.ServiceName,
+			Title:       service.openapi.title,
+			Description: service.openapi.description,
+			Version:     service.openapi.version,
+			OpenapiPath: service.OpenapiPath,
+			ResourceId:  service.ResourceId,
+			Workspaces:  append([]string{}, service.Workspaces...),
+			ProdServer:  service.ProdServer.document(),
+			MockServer:  service.MockServer.document(),
+			Tags:        []TagDocument{},
+			Apis:        []ApiDocument{},
+		}
+		tags := map[string]TagDocument{}
+		for _, tag := range service.openapi.tags {
+			tagKey, err := service.getTagKey(tag.name)
+			if err != nil {
+				return nil, err
+			}
+			tags[tag.name] = TagDocument{Name: tag.name, Description: tag.description, ResourceId: tagKey.ResourceId}
+			item.Tags = append(item.Tags, tags[tag.name])
+		}
+
+		for _, api := range service.openapi.apis {
+			// ApiKeyの取得
+			apiKey, err := service.getApikey(api.operationId)
+			if err != nil {
+				return nil, err
+			}
+			// Production/Mock
+			status := "mock"
+			if apiKey.Implemented {
+				status = "production"
+			}
+
+			detail := ApiDocument{
+				OperationId: api.operationId,
+				Method:      api.method,
+				Path:        api.path,
+				Summary:     api.summary,
+				Description: api.description,
+				Status:      status,
+				ApiKey: ApiKeyDocument{
+					KongId:      apiKey.KongId,
+					ResourceId:  apiKey.ResourceId,
+					Implemented: apiKey.Implemented,
+				},
+				Tags:      []TagDocument{},
+				Roles:     apiList.apiRoles(api),
+				RateLimit: api.effectiveRateLimit(&service.openapi).String(),
+				Request:   RequestDocument{Parameters: []ParameterDocument{}},
+				Responses: []ResponseDocument{},
+			}
+			for _, name := range api.tags {
+				detail.Tags = append(detail.Tags, tags[name])
+			}
+			for _, param := range api.request.parameters {
+				detail.Request.Parameters = append(detail.Request.Parameters, ParameterDocument{
+					Name:        param.name,
+					In:          param.in,
+					Required:    param.required,
+					Description: param.description,
+					Schema:      param.schema,
+					Type:        param.kind,
+					Example:     param.example,
+				})
+			}
+			if api.request.hasBody {
+				detail.Request.Body = &BodyDocument{
+					Description: api.request.name,
+					Required:    api.request.required,
+					ContentType: api.request.contentType,
+					Schema:      api.request.schema,
+					Fields:      fieldDocuments(api.request.fields),
+					Example:     api.request.example,
+				}
+			}
+			for _, res := range api.responses {
+				headers := []HeaderDocument{}
+				for _, header := range res.headers {
+					headers = append(headers, HeaderDocument{
+						Name:        header.name,
+						Required:    header.required,
+						Description: header.description,
+						Type:        header.kind,
+						Example:     header.example,
+					})
+				}
+				detail.Responses = append(detail.Responses, ResponseDocument{
+					Status:      res.status,
+					Description: res.name,
+					ContentType: res.contentType,
+					Schema:      res.schema,
+					Headers:     headers,
+					Fields:      fieldDocuments(res.fields),
+					Example:     res.example,
+				})
+			}
+			item.Apis = append(item.Apis, detail)
+		}
+		document.Services = append(document.Services, item)
+	}
+	return &document, nil
+}
+
+// FUNCTION: 公開モデル(Field)
+func fieldDocuments(fields []Field) []FieldDocument {
+	documents := []FieldDocument{}
+	for _, field := range fields {
+		documents = append(documents, FieldDocument{Name: field.name, Required: field.required, Description: field.description, Type: field.kind})
+	}
+	return documents
+}
+
+// FUNCTION: 公開モデル(Server)
+func (server Server) document() ServerDocument {
+	targets := []TargetDocument{}
+	for _, target := range server.Targets {
+		targets = append(targets, TargetDocument{Target: target.Target, Weight: target.weight()})
+	}
+	return ServerDocument{Host: server.Host, Port: server.Port, ServiceId: server.ServiceId, Targets: targets}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// パラメータ・リクエストボディ・レスポンスヘッダーを持つスキーマ
+const documentSpec = `
+openapi: 3.0.3
+info:
+  title: Orders
+  description: 受注API
+  version: 2.1.0
+tags:
+  - name: order
+paths:
+  /orders/{orderId}:
+    put:
+      tags: [order]
+      operationId: orders.put
+      summary: 受注更新
+      parameters:
+        - name: orderId
+          in: path
+          required: true
+          schema:
+            type: string
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [quantity]
+              properties:
+                quantity:
+                  type: integer
+      responses:
+        '200':
+          description: OK
+          headers:
+            X-Request-Id:
+              schema:
+                type: string
+    delete:
+      tags: [order]
+      operationId: orders.delete
+      responses:
+        '204':
+          description: No Content
+`
+
+// FUNCTION: 公開したJSONスキーマ(schema/api-list.schema.json)に適合する文書を出力すること
+func TestListJsonSchema(t *testing.T) {
+	apiList := ApiList{Services: []Service{newTestService(t, "orders", documentSpec, "orders.put")}}
+	path := filepath.Join(t.TempDir(), "api-list.json")
+	if err := apiList.ListJson(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var document, schema map[string]any
+	for target, file := range map[*map[string]any]string{&document: path, &schema: filepath.Join("..", "schema", "api-list.schema.json")} {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(source, target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := validateJson(schema, schema, document, "$"); len(errs) > 0 {
+		t.Errorf("schema violations:\n%s", strings.Join(errs, "\n"))
+	}
+	if document["version"] != DOCUMENT_VERSION || schema["$id"] != DOCUMENT_SCHEMA {
+		t.Errorf("version = %v, $id = %v", document["version"], schema["$id"])
+	}
+}
+
+// FUNCTION: JSONスキーマの検証(出力に使うキーワードのみ)
+func validateJson(root map[string]any, node map[string]any, value any, path string) []string {
+	if ref, ok := node["$ref"].(string); ok {
+		defs := root["$defs"].(map[string]any)
+		return validateJson(root, defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any), value, path)
+	}
+	if candidates, ok := node["oneOf"].([]any); ok {
+		for _, candidate := range candidates {
+			if len(validateJson(root, candidate.(map[string]any), value, path)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: matches no oneOf", path)}
+	}
+
+	errs := []string{}
+	switch kind, _ := node["type"].(string); kind {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: want object, got %T", path, value)}
+		}
+		for _, name := range node["required"].([]any) {
+			if _, ok := object[name.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing %s", path, name))
+			}
+		}
+		properties, _ := node["properties"].(map[string]any)
+		for name, item := range object {
+			if property, ok := properties[name]; ok {
+				errs = append(errs, validateJson(root, property.(map[string]any), item, path+"."+name)...)
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: want array, got %T", path, value)}
+		}
+		for i, item := range array {
+			errs = append(errs, validateJson(root, node["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: want string, got %T", path, value)}
+		}
+		if pattern, ok := node["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(text) {
+			errs = append(errs, fmt.Sprintf("%s: %q does not match %s", path, text, pattern))
+		}
+		if enum, ok := node["enum"].([]any); ok && !slices.Contains(enum, any(text)) {
+			errs = append(errs, fmt.Sprintf("%s: %q is not in %v", path, text, enum))
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != float64(int64(number)) {
+			errs = append(errs, fmt.Sprintf("%s: want integer, got %v", path, value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errs = append(errs, fmt.Sprintf("%s: want boolean, got %T", path, value))
+		}
+	case "null":
+		if value != nil {
+			errs = append(errs, fmt.Sprintf("%s: want null, got %T", path, value))
+		}
+	}
+	return errs
+}
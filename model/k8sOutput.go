@@ -0,0 +1,319 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+const (
+	HTTP_ROUTE = "httproute"
+	INGRESS    = "ingress"
+)
+
+// Gateway APIの上限値
+const (
+	MAX_MATCHES = 8
+	MAX_RULES   = 16
+)
+
+// TITLE: K8sOption構造体
+type K8sOption struct {
+	Kinds            []string
+	Namespace        string
+	Gateway          string
+	GatewayNamespace string
+	IngressClass     string
+}
+
+// TITLE: マニフェスト構造体
+type k8sMeta struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type httpRoute struct {
+	ApiVersion string        `yaml:"apiVersion"`
+	Kind       string        `yaml:"kind"`
+	Metadata   k8sMeta       `yaml:"metadata"`
+	Spec       httpRouteSpec `yaml:"spec"`
+}
+
+type httpRouteSpec struct {
+	ParentRefs []parentRef     `yaml:"parentRefs"`
+	Rules      []httpRouteRule `yaml:"rules"`
+}
+
+type parentRef struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type httpRouteRule struct {
+	Matches     []httpRouteMatch `yaml:"matches"`
+	BackendRefs []backendRef     `yaml:"backendRefs"`
+}
+
+type httpRouteMatch struct {
+	Path    pathMatch     `yaml:"path"`
+	Method  string        `yaml:"method"`
+	Headers []headerMatch `yaml:"headers,omitempty"`
+}
+
+type pathMatch struct {
+	Type  string `yaml:"type"`
+	Value string `yaml:"value"`
+}
+
+type headerMatch struct {
+	Type  string `yaml:"type"`
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type backendRef struct {
+	Name string `yaml:"name"`
+	Port int    `yaml:"port"`
+}
+
+type ingress struct {
+	ApiVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   k8sMeta     `yaml:"metadata"`
+	Spec       ingressSpec `yaml:"spec"`
+}
+
+type ingressSpec struct {
+	IngressClassName string        `yaml:"ingressClassName,omitempty"`
+	Rules            []ingressRule `yaml:"rules"`
+}
+
+type ingressRule struct {
+	Http ingressHttp `yaml:"http"`
+}
+
+type ingressHttp struct {
+	Paths []ingressPath `yaml:"paths"`
+}
+
+type ingressPath struct {
+	Path     string         `yaml:"path"`
+	PathType string         `yaml:"pathType"`
+	Backend  ingressBackend `yaml:"backend"`
+}
+
+type ingressBackend struct {
+	Service ingressService `yaml:"service"`
+}
+
+type ingressService struct {
+	Name string      `yaml:"name"`
+	Port ingressPort `yaml:"port"`
+}
+
+type ingressPort struct {
+	Number int `yaml:"number"`
+}
+
+// FUNCTION: Kubernetesマニフェストの書き込み(Service毎のマルチドキュメントYAML)
+func (apiList *ApiList) K8sManifests(dir string, option K8sOption) error {
+	apiList.warnTrafficIgnored("k8s")
+	for _, service := range apiList.Services {
+		routes, err := service.routes(apiList.MockHeader)
+		if err != nil {
+			return err
+		}
+		sort.SliceStable(routes, func(i, j int) bool {
+			return routes[i].Priority > routes[j].Priority
+		})
+		if err := service.k8sManifest(filepath.Join(dir, fmt.Sprintf("%s.yaml", service.ServiceName)), routes, option); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FUNCTION: Serviceのマニフェストの書き込み(Service毎にファイルを閉じる)
+// INFO: 作成できないマニフェストがある場合はファイルを作成しない
+func (service *Service) k8sManifest(path string, routes []Route, option K8sOption) error {
+	// PROCESS: マニフェストの作成
+	manifests := []any{}
+	for _, kind := range option.Kinds {
+		switch kind {
+		case HTTP_ROUTE:
+			for _, manifest := range service.httpRoutes(routes, option) {
+				manifests = append(manifests, manifest)
+			}
+		case INGRESS:
+			manifest, err := service.ingress(routes, option)
+			if err != nil {
+				return fmt.Errorf("service '%s': %w", service.ServiceName, err)
+			}
+			manifests = append(manifests, manifest)
+		default:
+			return fmt.Errorf("unknown manifest kind: %s", kind)
+		}
+	}
+
+	// PROCESS: Encoderの取得
+	encoder, cleanup, err := store.NewYamlEncorder(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	for _, manifest := range manifests {
+		if err := encoder.Encode(manifest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FUNCTION: HTTPRoute(Gateway API)
+// INFO: 転送先(とヘッダー条件)毎にルールをまとめ、Gateway APIの上限を超える場合は分割する
+func (service *Service) httpRoutes(routes []Route, option K8sOption) []httpRoute {
+	rules := []httpRouteRule{}
+	index := map[string]int{}
+	for _, route := range routes {
+		backend := service.backendRef(route)
+		match := httpRouteMatch{Path: route.pathMatch(), Method: route.Method}
+		for _, name := range sortedHeaderNames(route.Headers) {
+			match.Headers = append(match.Headers, headerMatch{Type: "Exact", Name: name, Value: route.Headers[name]})
+		}
+
+		key := fmt.Sprintf("%s:%d:%v", backend.Name, backend.Port, route.Headers)
+		i, ok := index[key]
+		if !ok || len(rules[i].Matches) >= MAX_MATCHES {
+			rules = append(rules, httpRouteRule{BackendRefs: []backendRef{backend}})
+			i = len(rules) - 1
+			index[key] = i
+		}
+		rules[i].Matches = append(rules[i].Matches, match)
+	}
+
+	manifests := []httpRoute{}
+	for i := 0; i < len(rules); i += MAX_RULES {
+		name := service.ServiceName
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d", service.ServiceName, i/MAX_RULES+1)
+		}
+		manifests = append(manifests, httpRoute{
+			ApiVersion: "gateway.networking.k8s.io/v1",
+			Kind:       "HTTPRoute",
+			Metadata:   service.k8sMeta(name, option, nil),
+			Spec: httpRouteSpec{
+				ParentRefs: []parentRef{{Name: option.Gateway, Namespace: option.GatewayNamespace}},
+				Rules:      rules[i:min(i+MAX_RULES, len(rules))],
+			},
+		})
+	}
+	return manifests
+}
+
+// FUNCTION: Ingress
+// INFO: Ingressはメソッド・ヘッダーで振り分けられないため、同一パスで転送先が異なる場合はエラーとする(HTTPRouteを使用する)
+func (service *Service) ingress(routes []Route, option K8sOption) (ingress, error) {
+	paths := []ingressPath{}
+	index := map[string]int{}
+	useRegex := false
+	for _, route := range routes {
+		if len(route.Headers) > 0 {
+			continue
+		}
+		match := route.pathMatch()
+		pathType := "Exact"
+		if match.Type == "RegularExpression" {
+			pathType = "ImplementationSpecific"
+			useRegex = true
+		}
+		backend := service.backendRef(route)
+		path := ingressPath{
+			Path:     match.Value,
+			PathType: pathType,
+			Backend:  ingressBackend{Service: ingressService{Name: backend.Name, Port: ingressPort{Number: backend.Port}}},
+		}
+
+		i, ok := index[match.Value]
+		if !ok {
+			index[match.Value] = len(paths)
+			paths = append(paths, path)
+			continue
+		}
+		if paths[i].Backend != path.Backend {
+			return ingress{}, fmt.Errorf("ingress cannot route '%s' by method, production and mock operations share the path (use --kind %s)", match.Value, HTTP_ROUTE)
+		}
+	}
+
+	annotations := map[string]string{}
+	if useRegex {
+		annotations["nginx.ingress.kubernetes.io/use-regex"] = "true"
+	}
+	return ingress{
+		ApiVersion: "networking.k8s.io/v1",
+		Kind:       "Ingress",
+		Metadata:   service.k8sMeta(service.ServiceName, option, annotations),
+		Spec: ingressSpec{
+			IngressClassName: option.IngressClass,
+			Rules:            []ingressRule{{Http: ingressHttp{Paths: paths}}},
+		},
+	}, nil
+}
+
+// FUNCTION: metadata
+func (service *Service) k8sMeta(name string, option K8sOption, annotations map[string]string) k8sMeta {
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	return k8sMeta{
+		Name:      name,
+		Namespace: option.Namespace,
+		Labels: map[string]string{
+			"app.kubernetes.io/name":       service.ServiceName,
+			"app.kubernetes.io/managed-by": "api-forge",
+		},
+		Annotations: annotations,
+	}
+}
+
+// FUNCTION: 転送先のService
+func (service *Service) backendRef(route Route) backendRef {
+	return backendRef{Name: route.Server.backendName(service.ServiceName, route.IsMock), Port: route.Server.Port}
+}
+
+// FUNCTION: KubernetesのService名(未指定の場合はサービス名、Mockは`-mock`を付与)
+func (server Server) backendName(serviceName string, isMock bool) string {
+	if server.K8sService != "" {
+		return server.K8sService
+	}
+	if isMock {
+		return fmt.Sprintf("%s-mock", serviceName)
+	}
+	return serviceName
+}
+
+// FUNCTION: パスの一致条件(パラメータを含む場合は正規表現)
+func (route Route) pathMatch() pathMatch {
+	if !re.MatchString(route.Path) {
+		return pathMatch{Type: "Exact", Value: fmt.Sprintf("/%s%s", route.ServiceName, route.Path)}
+	}
+	return pathMatch{Type: "RegularExpression", Value: fmt.Sprintf("^%s$", route.Regex)}
+}
+
+// FUNCTION: ヘッダー名(昇順)
+func sortedHeaderNames(headers map[string]string) []string {
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
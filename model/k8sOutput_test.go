@@ -0,0 +1,96 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"maps"
+	"testing"
+)
+
+// 同じパスに2つのオペレーションを持つスキーマ
+const samePathSpec = `
+openapi: 3.0.3
+info:
+  title: Orders
+  version: 1.0.0
+paths:
+  /receivings:
+    get:
+      operationId: receivings.get
+      responses:
+        '200':
+          description: OK
+    post:
+      operationId: receivings.post
+      responses:
+        '200':
+          description: OK
+`
+
+// FUNCTION: 実装済みのAPIがMockに転送されるIngressはエラーとすること
+func TestIngressSharedPath(t *testing.T) {
+	for _, tt := range []struct {
+		implemented []string
+		err         bool
+	}{
+		{[]string{"receivings.post"}, true},
+		{nil, false},
+		{[]string{"receivings.get", "receivings.post"}, false},
+	} {
+		service := newTestService(t, "orders", samePathSpec, tt.implemented...)
+		routes, err := service.routes(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		manifest, err := service.ingress(routes, K8sOption{})
+		if (err != nil) != tt.err {
+			t.Errorf("implemented=%v: err = %v, want error %t", tt.implemented, err, tt.err)
+			continue
+		}
+		if err == nil && len(manifest.Spec.Rules[0].Http.Paths) != 1 {
+			t.Errorf("implemented=%v: paths = %v", tt.implemented, manifest.Spec.Rules[0].Http.Paths)
+		}
+	}
+}
+
+// FUNCTION: HTTPRouteは転送先・ヘッダー条件毎にルールをまとめること
+func TestHttpRoutes(t *testing.T) {
+	service := newTestService(t, "sample", conflictSpec, "items.search")
+	service.ProdServer.K8sService = "sample-api"
+	routes, err := service.routes(&MockHeader{Name: "X-Mock"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifests := service.httpRoutes(routes, K8sOption{Gateway: "gateway"})
+	if len(manifests) != 1 {
+		t.Fatalf("manifests = %d, want 1", len(manifests))
+	}
+	rules := map[string]int{}
+	for _, rule := range manifests[0].Spec.Rules {
+		backend := rule.BackendRefs[0]
+		key := fmt.Sprintf("%s:%d", backend.Name, backend.Port)
+		if len(rule.Matches[0].Headers) > 0 {
+			key += "+header"
+		}
+		rules[key] = len(rule.Matches)
+	}
+	want := map[string]int{"sample-api:8080": 1, "sample-mock:8081": 3, "sample-mock:8081+header": 4}
+	if !maps.Equal(rules, want) {
+		t.Errorf("rules = %v, want %v", rules, want)
+	}
+
+	// PROCESS: パスの一致条件
+	for _, route := range routes {
+		match := route.pathMatch()
+		if route.ApiKey.OperationId == "items.search" && (match.Type != "Exact" || match.Value != "/sample/items/search") {
+			t.Errorf("items.search path = %v", match)
+		}
+		if route.ApiKey.OperationId == "items.get" && match.Type != "RegularExpression" {
+			t.Errorf("items.get path = %v", match)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+const (
+	DRIFT_MISSING = "missing"
+	DRIFT_EXTRA   = "extra"
+	DRIFT_CHANGED = "changed"
+)
+
+// TITLE: Drift構造体(生成されるデータと稼働中のデータの差異)
+type Drift struct {
+	Kind    string
+	Id      string
+	Name    string
+	Status  string
+	Details []string
+}
+
+// FUNCTION: Sql4Kong/Sql4Aclが作成するデータ
+func (apiList *ApiList) expectedState() (*KongState, error) {
+	state := KongState{Services: []KongService{}, Routes: []KongRoute{}, Resources: []AclResource{}, ApiResources: []AclApiResource{}, Relations: []AclRelation{}}
+	scopes, err := apiList.scopedServices()
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		service := scope.Service
+		// PROCESS: Service
+		state.Services = append(state.Services,
+			KongService{
+				Id:   service.ProdServer.ServiceId,
+				Name: service.openapi.description,
+				Host: service.ProdServer.kongHost(service.ServiceName, false),
+				Port: service.ProdServer.Port,
+				Tags: []string{service.ServiceName},
+			},
+			KongService{
+				Id:   service.MockServer.ServiceId,
+				Name: fmt.Sprintf("%s(MOCK)", service.openapi.description),
+				Host: service.MockServer.kongHost(service.ServiceName, true),
+				Port: service.MockServer.Port,
+				Tags: []string{service.ServiceName, "mock"},
+			},
+		)
+
+		// PROCESS: Route
+		routes, err := service.routes(apiList.MockHeader)
+		if err != nil {
+			return nil, err
+		}
+		for _, route := range routes {
+			headers := map[string][]string{}
+			for name, value := range route.Headers {
+				headers[name] = []string{value}
+			}
+			state.Routes = append(state.Routes, KongRoute{
+				Id:        route.Id,
+				Name:      route.Name,
+				ServiceId: route.Server.ServiceId,
+				Methods:   []string{route.Method},
+				Paths:     []string{"~" + route.Regex},
+				Headers:   headers,
+				Tags:      route.Tags(),
+			})
+		}
+
+		// PROCESS: ACL(Routeとの対応)
+		for _, api := range service.openapi.apis {
+			apiKey, err := service.getApikey(api.operationId)
+			if err != nil {
+				return nil, err
+			}
+			state.ApiResources = append(state.ApiResources, AclApiResource{ResourceId: apiKey.ResourceId, KongId: apiKey.KongId})
+		}
+	}
+
+	for _, service := range apiList.Services {
+		// PROCESS: ACL
+		parents, err := service.parentResources()
+		if err != nil {
+			return nil, err
+		}
+		state.Resources = append(state.Resources, parents...)
+		relations, err := service.resourceRelations()
+		if err != nil {
+			return nil, err
+		}
+		state.Relations = append(state.Relations, relations...)
+		for _, api := range service.openapi.apis {
+			apiKey, err := service.getApikey(api.operationId)
+			if err != nil {
+				return nil, err
+			}
+			state.Resources = append(state.Resources, AclResource{
+				ResourceId:   apiKey.ResourceId,
+				ResourceType: RESOURCE_API,
+				ResourceName: fmt.Sprintf("%s(%s)", api.summary, api.operationId),
+			})
+		}
+	}
+	return &state, nil
+}
+
+// FUNCTION: 稼働中のデータとの差異
+// INFO: 稼働中のデータにACLのテーブルが含まれない場合、ACLは比較しない
+func (apiList *ApiList) KongDrift(deployed *KongState, option AclOption) ([]Drift, error) {
+	expected, err := apiList.expectedState()
+	if err != nil {
+		return nil, err
+	}
+	drifts := []Drift{}
+
+	// PROCESS: Service
+	drifts = append(drifts, diffById("service", expected.Services, deployed.Services,
+		func(item KongService) (string, string) { return item.Id, item.Name },
+		func(want KongService, got KongService) []string {
+			details := []string{}
+			details = appendChange(details, "host", want.Host, got.Host)
+			details = appendChange(details, "port", fmt.Sprint(want.Port), fmt.Sprint(got.Port))
+			details = appendChange(details, "tags", setString(want.Tags), setString(got.Tags))
+			return details
+		})...)
+
+	// PROCESS: Route
+	drifts = append(drifts, diffById("route", expected.Routes, deployed.Routes,
+		func(item KongRoute) (string, string) { return item.Id, item.Name },
+		func(want KongRoute, got KongRoute) []string {
+			details := []string{}
+			details = appendChange(details, "paths", setString(want.Paths), setString(got.Paths))
+			details = appendChange(details, "methods", setString(want.Methods), setString(got.Methods))
+			details = appendChange(details, "service", want.ServiceId, got.ServiceId)
+			details = appendChange(details, "tags", setString(want.Tags), setString(got.Tags))
+			details = appendChange(details, "headers", headerString(want.Headers), headerString(got.Headers))
+			return details
+		})...)
+
+	// PROCESS: ACL(テーブル名はSql4Aclと同じ)
+	if !deployed.hasAcl {
+		return drifts, nil
+	}
+	schema := "acl"
+	if option.Schema != nil {
+		schema = *option.Schema
+	}
+	tables := option.Tables.withDefaults()
+	drifts = append(drifts, diffById(qualify(schema, tables.Resources, ""), expected.Resources, deployed.Resources,
+		func(item AclResource) (string, string) { return item.ResourceId, item.ResourceName },
+		func(want AclResource, got AclResource) []string {
+			details := []string{}
+			details = appendChange(details, "resource_type", want.ResourceType, got.ResourceType)
+			details = appendChange(details, "resource_name", want.ResourceName, got.ResourceName)
+			return details
+		})...)
+	drifts = append(drifts, diffById(qualify(schema, tables.ApiResources, ""), expected.ApiResources, deployed.ApiResources,
+		func(item AclApiResource) (string, string) { return item.ResourceId + ":" + item.KongId, "" },
+		func(want AclApiResource, got AclApiResource) []string { return []string{} })...)
+	drifts = append(drifts, diffById(qualify(schema, tables.ResourceRelations, ""), expected.Relations, deployed.Relations,
+		func(item AclRelation) (string, string) { return item.ResourceId + ":" + item.ParentResourceId, "" },
+		func(want AclRelation, got AclRelation) []string { return []string{} })...)
+
+	return drifts, nil
+}
+
+// FUNCTION: IDをキーとした差異の検出
+func diffById[T any](kind string, expected []T, deployed []T, key func(T) (string, string), compare func(T, T) []string) []Drift {
+	drifts := []Drift{}
+	deployedMap := map[string]T{}
+	for _, item := range deployed {
+		id, _ := key(item)
+		deployedMap[id] = item
+	}
+	expectedIds := map[string]bool{}
+
+	for _, want := range expected {
+		id, name := key(want)
+		expectedIds[id] = true
+		got, ok := deployedMap[id]
+		if !ok {
+			drifts = append(drifts, Drift{Kind: kind, Id: id, Name: name, Status: DRIFT_MISSING})
+			continue
+		}
+		if details := compare(want, got); len(details) > 0 {
+			drifts = append(drifts, Drift{Kind: kind, Id: id, Name: name, Status: DRIFT_CHANGED, Details: details})
+		}
+	}
+	for _, got := range deployed {
+		id, name := key(got)
+		if !expectedIds[id] {
+			drifts = append(drifts, Drift{Kind: kind, Id: id, Name: name, Status: DRIFT_EXTRA})
+		}
+	}
+	return drifts
+}
+
+// FUNCTION: 差異の追記
+func appendChange(details []string, name string, want string, got string) []string {
+	if want == got {
+		return details
+	}
+	return append(details, fmt.Sprintf("%s: `%s` => `%s`", name, got, want))
+}
+
+// FUNCTION: 順不同の比較用文字列
+func setString(values []string) string {
+	sorted := slices.Clone(values)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+// FUNCTION: ヘッダー条件の比較用文字列
+func headerString(headers map[string][]string) string {
+	items := []string{}
+	for name, values := range headers {
+		items = append(items, fmt.Sprintf("%s=%s", strings.ToLower(name), setString(values)))
+	}
+	return setString(items)
+}
+
+// FUNCTION: 差異レポート(MD)の書き込み
+func (apiList *ApiList) KongDriftMd(path string, drifts []Drift) error {
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	file.WriteString("# Kong/ACL drift report\n")
+	file.WriteString("\n`changed` shows `deployed => generated`.\n")
+
+	if len(drifts) == 0 {
+		file.WriteString("\nNo drift.\n")
+		return nil
+	}
+	kinds := []string{}
+	for _, drift := range drifts {
+		if !slices.Contains(kinds, drift.Kind) {
+			kinds = append(kinds, drift.Kind)
+		}
+	}
+	for _, kind := range kinds {
+		items := []Drift{}
+		for _, drift := range drifts {
+			if drift.Kind == kind {
+				items = append(items, drift)
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+		file.WriteString(fmt.Sprintf("\n## %s\n\n", kind))
+		file.WriteString("  | Status | Id | Name | Details |\n")
+		file.WriteString("  |---|---|---|---|\n")
+		for _, item := range items {
+			file.WriteString(fmt.Sprintf("  | %s | %s | %s | %s |\n",
+				item.Status,
+				item.Id,
+				item.Name,
+				strings.Join(item.Details, "<br>"),
+			))
+		}
+	}
+	return nil
+}
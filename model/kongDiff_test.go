@@ -0,0 +1,120 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// タグを持つスキーマ
+const taggedSpec = `
+openapi: 3.0.3
+info:
+  title: Items
+  description: 商品API
+  version: 1.0.0
+tags:
+  - name: item
+    description: 商品
+paths:
+  /items:
+    get:
+      tags: [item]
+      operationId: items.get
+      summary: 商品一覧取得
+      responses:
+        '200':
+          description: OK
+    post:
+      tags: [item]
+      operationId: items.post
+      summary: 商品登録
+      responses:
+        '201':
+          description: Created
+`
+
+// FUNCTION: 独自のスキーマ・テーブル名で出力したACLのSQLを差異無しと判定すること
+func TestKongDriftCustomAclTables(t *testing.T) {
+	apiList := ApiList{Services: []Service{newTestService(t, "sample", taggedSpec, "items.get")}}
+	dir := t.TempDir()
+
+	// PROCESS: SQL出力
+	schema := "Auth"
+	option := AclOption{Dialect: "postgres", Schema: &schema, Tables: AclTables{Resources: "acl_resources", ApiResources: "acl_api_resources"}}
+	kongPath := filepath.Join(dir, "kongData.sql")
+	aclPath := filepath.Join(dir, "aclData.sql")
+	if err := apiList.Sql4Kong(kongPath, Audit{}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := apiList.Sql4Acl(aclPath, option, Audit{}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// PROCESS: Kongのみ(ACLは比較しない)
+	state, err := LoadKongState(option, kongPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.HasAcl() {
+		t.Error("kong dump must not contain acl tables")
+	}
+	drifts, err := apiList.KongDrift(state, option)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifts) != 0 {
+		t.Errorf("drifts = %v, want none", drifts)
+	}
+
+	// PROCESS: Kong+ACL
+	state, err = LoadKongState(option, kongPath, aclPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.HasAcl() || len(state.Resources) != 4 {
+		t.Fatalf("resources = %d, want 4", len(state.Resources))
+	}
+	drifts, err = apiList.KongDrift(state, option)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifts) != 0 {
+		t.Errorf("drifts = %v, want none", drifts)
+	}
+}
+
+// FUNCTION: 稼働中のデータとの差異を変更・不足・余剰として検出すること
+func TestKongDrift(t *testing.T) {
+	apiList := ApiList{Services: []Service{newTestService(t, "sample", taggedSpec, "items.get")}}
+	kongPath := filepath.Join(t.TempDir(), "kongData.sql")
+	if err := apiList.Sql4Kong(kongPath, Audit{}, false); err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadKongState(AclOption{}, kongPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PROCESS: 稼働中のデータを変更(1件目のRouteのメソッド変更・2件目のRouteを削除・未知のRouteを追加)
+	state.Routes[0].Methods = []string{"PUT"}
+	missing := state.Routes[1].Id
+	state.Routes[1] = KongRoute{Id: "unknown", ServiceId: state.Routes[0].ServiceId}
+
+	drifts, err := apiList.KongDrift(state, AclOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[string]Drift{}
+	for _, drift := range drifts {
+		statuses[drift.Status] = drift
+	}
+	if changed := statuses[DRIFT_CHANGED]; len(drifts) != 3 || changed.Id != state.Routes[0].Id || len(changed.Details) != 1 {
+		t.Errorf("drifts = %v", drifts)
+	}
+	if statuses[DRIFT_MISSING].Id != missing || statuses[DRIFT_EXTRA].Id != "unknown" {
+		t.Errorf("drifts = %v", drifts)
+	}
+}
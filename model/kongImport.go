@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TITLE: ImportResult構造体
+type ImportResult struct {
+	Matched         []string
+	UnmatchedRoutes []KongRoute
+	UnmatchedApis   []string
+}
+
+// FUNCTION: 稼働中のKongのIDを取り込む
+// INFO: メソッドとパスが一致するRouteのIDをKongId、その転送先のIDをServiceIdとして設定する
+// INFO: implementedは転送先(Production/Mock)と異なる場合に警告し、syncStatusの場合のみ転送先に合わせる
+func (apiList *ApiList) ImportKong(state *KongState, syncStatus bool) ImportResult {
+	result := ImportResult{Matched: []string{}, UnmatchedRoutes: []KongRoute{}, UnmatchedApis: []string{}}
+	used := map[string]bool{}
+	assigned := map[string]bool{}
+
+	for i := range apiList.Services {
+		service := &apiList.Services[i]
+		for _, api := range service.openapi.apis {
+			route, ok := state.findRoute(service.ServiceName, api, used)
+			if !ok {
+				result.UnmatchedApis = append(result.UnmatchedApis, fmt.Sprintf("%s %s(%s)", strings.ToUpper(api.method), routeRegex(service.ServiceName, api.path), api.operationId))
+				continue
+			}
+			used[route.Id] = true
+			// INFO: 本ツールが作成したヘッダー切替用のMock Routeは取り込み対象外
+			used[deriveId(route.Id, "mock")] = true
+
+			// PROCESS: 転送先(Production/Mock)の設定
+			implemented := true
+			if kongService, ok := state.service(route.ServiceId); ok {
+				implemented = !kongService.isMock()
+				server := &service.ProdServer
+				if !implemented {
+					server = &service.MockServer
+				}
+				key := fmt.Sprintf("%s:%t", service.ServiceName, implemented)
+				if !assigned[key] {
+					server.ServiceId = kongService.Id
+					assigned[key] = true
+				} else if server.ServiceId != kongService.Id {
+					log.Printf("WARNING: '%s' routes to another kong service(%s).", api.operationId, kongService.Id)
+				}
+			}
+
+			// PROCESS: ApiKeyの設定
+			for j := range service.Apis {
+				if service.Apis[j].OperationId != api.operationId {
+					continue
+				}
+				service.Apis[j].KongId = route.Id
+				if service.Apis[j].Implemented == implemented {
+					continue
+				}
+				if syncStatus {
+					log.Printf("WARNING: '%s' implemented changed to %t by the deployed route.", api.operationId, implemented)
+					service.Apis[j].Implemented = implemented
+				} else {
+					log.Printf("WARNING: '%s' is routed as implemented=%t, kept %t (use --sync-status to change).", api.operationId, implemented, service.Apis[j].Implemented)
+				}
+			}
+			result.Matched = append(result.Matched, fmt.Sprintf("%s %s(%s) <= %s", strings.ToUpper(api.method), routeRegex(service.ServiceName, api.path), api.operationId, route.Id))
+		}
+	}
+
+	// PROCESS: 取り込まれなかったRoute
+	for _, route := range state.Routes {
+		if !used[route.Id] {
+			result.UnmatchedRoutes = append(result.UnmatchedRoutes, route)
+		}
+	}
+	return result
+}
+
+// FUNCTION: APIに対応するRouteの検索(ヘッダー条件の無いRouteを優先する)
+func (state *KongState) findRoute(serviceName string, api Api, used map[string]bool) (KongRoute, bool) {
+	pattern := pathPattern(fmt.Sprintf("/%s%s", serviceName, api.path), false)
+	var found *KongRoute
+	for i, route := range state.Routes {
+		if used[route.Id] || !route.matchMethod(api.method) {
+			continue
+		}
+		for _, path := range route.Paths {
+			if pathPattern(path, true) != pattern {
+				continue
+			}
+			if found == nil || (len(found.Headers) > 0 && len(route.Headers) == 0) {
+				found = &state.Routes[i]
+			}
+		}
+	}
+	if found == nil {
+		return KongRoute{}, false
+	}
+	return *found, true
+}
+
+// FUNCTION: メソッドの一致(メソッド指定の無いRouteは全メソッドに一致)
+func (route KongRoute) matchMethod(method string) bool {
+	return len(route.Methods) == 0 || slices.Contains(route.Methods, strings.ToUpper(method))
+}
+
+// FUNCTION: パスの比較用パターン(パラメータ・正規表現を含むセグメントを`{}`に置き換える)
+func pathPattern(path string, isKong bool) string {
+	if isKong {
+		path = strings.TrimPrefix(path, "~")
+		path = strings.TrimPrefix(path, "^")
+		path = strings.TrimSuffix(path, "$")
+	}
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range segs {
+		// INFO: エスケープされた固定文字列(`v1\.0`など)は元の文字列として比較する
+		if isKong {
+			if literal := unquoteMeta(seg); regexp.QuoteMeta(literal) == seg {
+				segs[i] = literal
+				continue
+			}
+		}
+		if (isKong && strings.ContainsAny(seg, `[](){}+*?\.|`)) || (!isKong && re.MatchString(seg)) {
+			segs[i] = "{}"
+		}
+	}
+	return "/" + strings.Join(segs, "/")
+}
+
+// FUNCTION: QuoteMetaのエスケープの除去
+func unquoteMeta(value string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String()
+}
+
+// FUNCTION: Routeの表記
+func (route KongRoute) String() string {
+	return fmt.Sprintf("%s %s(%s) [%s]", strings.Join(route.Methods, ","), strings.Join(route.Paths, ","), route.Name, route.Id)
+}
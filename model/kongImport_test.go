@@ -0,0 +1,79 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 稼働中のKongの設定(decK形式)
+const deckDump = `
+services:
+  - id: 11111111-1111-1111-1111-111111111111
+    name: orders
+    host: orders
+    port: 8080
+    routes:
+      - id: 22222222-2222-2222-2222-222222222222
+        methods: [GET]
+        paths: ['~/orders/receivings$']
+  - id: 33333333-3333-3333-3333-333333333333
+    name: orders(MOCK)
+    host: orders-mock
+    port: 8081
+    tags: [orders, mock]
+routes:
+  - id: 44444444-4444-4444-4444-444444444444
+    methods: [POST]
+    paths: ['/orders/receivings']
+    service:
+      name: orders(MOCK)
+  - id: 55555555-5555-5555-5555-555555555555
+    methods: [DELETE]
+    paths: ['/orders/receivings']
+    service:
+      name: orders
+`
+
+// FUNCTION: メソッド・パスが一致するRouteのIDと転送先を取り込むこと
+func TestImportKong(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kong.yaml")
+	if err := os.WriteFile(path, []byte(deckDump), 0666); err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadKongState(AclOption{}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, syncStatus := range []bool{false, true} {
+		apiList := ApiList{Services: []Service{newTestService(t, "orders", samePathSpec, "receivings.post")}}
+		result := apiList.ImportKong(state, syncStatus)
+		if len(result.Matched) != 2 || len(result.UnmatchedApis) != 0 {
+			t.Errorf("matched = %v, unmatched apis = %v", result.Matched, result.UnmatchedApis)
+		}
+		if len(result.UnmatchedRoutes) != 1 || result.UnmatchedRoutes[0].Id != "55555555-5555-5555-5555-555555555555" {
+			t.Errorf("unmatched routes = %v", result.UnmatchedRoutes)
+		}
+
+		service := apiList.Services[0]
+		if service.ProdServer.ServiceId != "11111111-1111-1111-1111-111111111111" || service.MockServer.ServiceId != "33333333-3333-3333-3333-333333333333" {
+			t.Errorf("service ids = %s, %s", service.ProdServer.ServiceId, service.MockServer.ServiceId)
+		}
+		apis := map[string]ApiKey{}
+		for _, apiKey := range service.Apis {
+			apis[apiKey.OperationId] = apiKey
+		}
+		if apis["receivings.get"].KongId != "22222222-2222-2222-2222-222222222222" || apis["receivings.post"].KongId != "44444444-4444-4444-4444-444444444444" {
+			t.Errorf("kong ids = %v", apis)
+		}
+
+		// PROCESS: 実装状況は--sync-statusの場合のみ転送先に合わせる
+		if get, post := apis["receivings.get"].Implemented, apis["receivings.post"].Implemented; get != syncStatus || post == syncStatus {
+			t.Errorf("syncStatus=%t: implemented = get:%t post:%t", syncStatus, get, post)
+		}
+	}
+}
@@ -0,0 +1,529 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TITLE: KongState構造体(稼働中のKongおよびACLの設定)
+type KongState struct {
+	Services     []KongService    `json:"services"`
+	Routes       []KongRoute      `json:"routes"`
+	Resources    []AclResource    `json:"resources"`
+	ApiResources []AclApiResource `json:"api_resources"`
+	Relations    []AclRelation    `json:"resource_relations"`
+	hasAcl       bool
+}
+
+type KongService struct {
+	Id   string   `json:"id"`
+	Name string   `json:"name"`
+	Host string   `json:"host"`
+	Port int      `json:"port"`
+	Tags []string `json:"tags"`
+}
+
+type KongRoute struct {
+	Id        string              `json:"id"`
+	Name      string              `json:"name"`
+	ServiceId string              `json:"service_id"`
+	Methods   []string            `json:"methods"`
+	Paths     []string            `json:"paths"`
+	Headers   map[string][]string `json:"headers"`
+	Tags      []string            `json:"tags"`
+}
+
+type AclResource struct {
+	ResourceId   string `json:"resource_id"`
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+}
+
+type AclApiResource struct {
+	ResourceId string `json:"resource_id"`
+	KongId     string `json:"kong_id"`
+}
+
+type AclRelation struct {
+	ResourceId       string `json:"resource_id"`
+	ParentResourceId string `json:"parent_resource_id"`
+}
+
+// TITLE: SQLのINSERT/COPYから読み込んだ行
+type sqlRow struct {
+	table   string
+	columns []string
+	values  []string
+}
+
+// 列名を指定しない場合のテーブル定義(本ツールの出力順、ACLはaclTableNamesで解決した名称)
+var defaultColumns = map[string][]string{
+	"acl:resources":          {"resource_id", "resource_type", "resource_name", "created_at", "updated_at", "created_by", "updated_by"},
+	"acl:api_resources":      {"resource_id", "kong_id", "created_at", "updated_at", "created_by", "updated_by"},
+	"acl:resource_relations": {"resource_id", "parent_resource_id", "created_at", "updated_at", "created_by", "updated_by"},
+	"service":                {"id", "created_at", "updated_at", "name", "retries", "protocol", "host", "port", "path", "connect_timeout", "write_timeout", "read_timeout", "tags", "client_certificate_id", "tls_verify", "tls_verify_depth", "ca_certificates", "ws_id", "enabled"},
+	"route":                  {"id", "created_at", "updated_at", "name", "service_id", "protocols", "methods", "hosts", "paths", "snis", "sources", "destinations", "regex_priority", "strip_path", "preserve_host", "tags", "https_redirect_status_code", "headers", "path_handling", "ws_id", "request_buffering", "response_buffering", "expression", "priority"},
+}
+
+var (
+	insertStatement = regexp.MustCompile(`(?is)^INSERT\s+INTO\s+([^\s(]+)\s*(\(([^)]*)\))?\s*VALUES\s*(.*)$`)
+	copyStatement   = regexp.MustCompile(`(?i)^COPY\s+([^\s(]+)\s*\(([^)]*)\)\s+FROM\s+stdin;?$`)
+)
+
+// FUNCTION: Kongの設定の読込み(複数ファイルの場合は結合する)
+// INFO: ACLのテーブルはSql4Aclと同じスキーマ・テーブル名で判定する
+func LoadKongState(option AclOption, paths ...string) (*KongState, error) {
+	state := KongState{Services: []KongService{}, Routes: []KongRoute{}, Resources: []AclResource{}, ApiResources: []AclApiResource{}, Relations: []AclRelation{}}
+	for _, path := range paths {
+		loaded, err := loadKongState(path, aclTableNames(option))
+		if err != nil {
+			return nil, err
+		}
+		state.Services = append(state.Services, loaded.Services...)
+		state.Routes = append(state.Routes, loaded.Routes...)
+		state.Resources = append(state.Resources, loaded.Resources...)
+		state.ApiResources = append(state.ApiResources, loaded.ApiResources...)
+		state.Relations = append(state.Relations, loaded.Relations...)
+		state.hasAcl = state.hasAcl || loaded.hasAcl
+	}
+	return &state, nil
+}
+
+// FUNCTION: ACLのデータを含むかどうか
+func (state *KongState) HasAcl() bool {
+	return state.hasAcl
+}
+
+// FUNCTION: 1ファイルの読込み(拡張子で形式を判定する)
+func loadKongState(path string, aclTables map[string]string) (*KongState, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".sql":
+		rows, err := readSqlRows(path)
+		if err != nil {
+			return nil, err
+		}
+		return newKongStateFromRows(rows, aclTables), nil
+	case ".yaml", ".yml":
+		return readDeck(path)
+	case ".json":
+		return readKongJson(path)
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", path)
+	}
+}
+
+// FUNCTION: ACLのテーブル名(dumpでの表記)から既定のテーブル名への対応
+func aclTableNames(option AclOption) map[string]string {
+	schema := "acl"
+	if option.Schema != nil {
+		schema = *option.Schema
+	}
+	tables := option.Tables.withDefaults()
+	return map[string]string{
+		normalizeTable(qualify(schema, tables.Resources, "")):         "acl:resources",
+		normalizeTable(qualify(schema, tables.ApiResources, "")):      "acl:api_resources",
+		normalizeTable(qualify(schema, tables.ResourceRelations, "")): "acl:resource_relations",
+	}
+}
+
+// FUNCTION: SQLの行からKongStateを作成
+func newKongStateFromRows(rows []sqlRow, aclTables map[string]string) *KongState {
+	state := KongState{Services: []KongService{}, Routes: []KongRoute{}, Resources: []AclResource{}, ApiResources: []AclApiResource{}, Relations: []AclRelation{}}
+	for _, row := range rows {
+		if table, ok := aclTables[row.table]; ok {
+			row.table = table
+			state.hasAcl = true
+		}
+		switch row.table {
+		case "service", "services":
+			port, _ := strconv.Atoi(row.get("port"))
+			state.Services = append(state.Services, KongService{
+				Id:   row.get("id"),
+				Name: row.get("name"),
+				Host: row.get("host"),
+				Port: port,
+				Tags: parseSqlArray(row.get("tags")),
+			})
+		case "route", "routes":
+			state.Routes = append(state.Routes, KongRoute{
+				Id:        row.get("id"),
+				Name:      row.get("name"),
+				ServiceId: row.get("service_id"),
+				Methods:   parseSqlArray(row.get("methods")),
+				Paths:     parseSqlArray(row.get("paths")),
+				Headers:   parseSqlHeaders(row.get("headers")),
+				Tags:      parseSqlArray(row.get("tags")),
+			})
+		case "acl:resources":
+			state.Resources = append(state.Resources, AclResource{
+				ResourceId:   row.get("resource_id"),
+				ResourceType: row.get("resource_type"),
+				ResourceName: row.get("resource_name"),
+			})
+		case "acl:api_resources":
+			state.ApiResources = append(state.ApiResources, AclApiResource{
+				ResourceId: row.get("resource_id"),
+				KongId:     row.get("kong_id"),
+			})
+		case "acl:resource_relations":
+			state.Relations = append(state.Relations, AclRelation{
+				ResourceId:       row.get("resource_id"),
+				ParentResourceId: row.get("parent_resource_id"),
+			})
+		}
+	}
+	return &state
+}
+
+// FUNCTION: JSON形式ファイルの読込み(KongState構造体と同じ形式)
+func readKongJson(path string) (*KongState, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file: %w", err)
+	}
+	var state KongState
+	if err := json.Unmarshal(file, &state); err != nil {
+		return nil, err
+	}
+	state.hasAcl = state.Resources != nil || state.ApiResources != nil || state.Relations != nil
+	return &state, nil
+}
+
+// FUNCTION: 列の値(列名が無い場合はKongのテーブル定義順)
+func (row sqlRow) get(column string) string {
+	columns := row.columns
+	if len(columns) == 0 {
+		columns = defaultColumns[row.table]
+		if columns == nil {
+			columns = defaultColumns[strings.TrimSuffix(row.table, "s")]
+		}
+	}
+	for i, name := range columns {
+		if name == column && i < len(row.values) {
+			return row.values[i]
+		}
+	}
+	return ""
+}
+
+// FUNCTION: SQLファイルの読込み(INSERT文およびpg_dumpのCOPY形式)
+func readSqlRows(path string) ([]sqlRow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file: %w", err)
+	}
+	defer file.Close()
+
+	rows := []sqlRow{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+
+	var statement strings.Builder
+	var copyRow *sqlRow
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// PROCESS: COPY形式のデータ行
+		if copyRow != nil {
+			if line == `\.` {
+				copyRow = nil
+				continue
+			}
+			values := strings.Split(line, "\t")
+			for i, value := range values {
+				values[i] = unescapeCopyValue(value)
+			}
+			rows = append(rows, sqlRow{table: copyRow.table, columns: copyRow.columns, values: values})
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if statement.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		if matches := copyStatement.FindStringSubmatch(trimmed); matches != nil {
+			copyRow = &sqlRow{table: normalizeTable(matches[1]), columns: splitColumns(matches[2])}
+			continue
+		}
+
+		// PROCESS: 文末(`;`)までを1文として扱う
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if text, complete := completeStatement(statement.String()); complete {
+			statement.Reset()
+			if matches := insertStatement.FindStringSubmatch(strings.TrimSpace(text)); matches != nil {
+				for _, tuple := range splitSqlTuples(matches[4]) {
+					rows = append(rows, sqlRow{
+						table:   normalizeTable(matches[1]),
+						columns: splitColumns(matches[3]),
+						values:  tuple,
+					})
+				}
+			}
+		}
+	}
+	return rows, scanner.Err()
+}
+
+// FUNCTION: 文字列リテラル外の`;`で文が完結しているか
+func completeStatement(text string) (string, bool) {
+	inQuote := false
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\'':
+			inQuote = !inQuote
+		case text[i] == ';' && !inQuote:
+			return text[:i], true
+		}
+	}
+	return text, false
+}
+
+// FUNCTION: VALUES句のタプル分割
+func splitSqlTuples(text string) [][]string {
+	tuples := [][]string{}
+	depth := 0
+	inQuote := false
+	var value strings.Builder
+	values := []string{}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inQuote:
+			value.WriteByte(c)
+			if c == '\'' {
+				if i+1 < len(text) && text[i+1] == '\'' {
+					value.WriteByte(c)
+					i++
+				} else {
+					inQuote = false
+				}
+			}
+		case c == '\'':
+			inQuote = true
+			value.WriteByte(c)
+		case depth == 0 && len(tuples) > 0 && c != ',' && !unicode.IsSpace(rune(c)):
+			// INFO: `ON CONFLICT ...`/`ON DUPLICATE KEY ...`などVALUES句の後ろは読み飛ばす
+			return tuples
+		case c == '(' || c == '[':
+			if depth > 0 {
+				value.WriteByte(c)
+			}
+			depth++
+		case c == ')' || c == ']':
+			depth--
+			if depth > 0 {
+				value.WriteByte(c)
+			}
+			if depth == 0 {
+				values = append(values, unquoteSqlValue(value.String()))
+				tuples = append(tuples, values)
+				values = []string{}
+				value.Reset()
+			}
+		case c == ',' && depth == 1:
+			values = append(values, unquoteSqlValue(value.String()))
+			value.Reset()
+		case depth > 0:
+			value.WriteByte(c)
+		}
+	}
+	return tuples
+}
+
+// FUNCTION: SQLの値の正規化(文字列リテラルは引用符を除去、nullは空文字)
+func unquoteSqlValue(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "null") {
+		return ""
+	}
+	// INFO: `'...'::jsonb`のような型キャストは除去する
+	if i := strings.LastIndex(value, "'::"); i > 0 && strings.HasPrefix(value, "'") {
+		value = value[:i+1]
+	}
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	return value
+}
+
+// FUNCTION: COPY形式の値の正規化
+func unescapeCopyValue(value string) string {
+	if value == `\N` {
+		return ""
+	}
+	replacer := strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\\`, `\`)
+	return replacer.Replace(value)
+}
+
+// FUNCTION: 配列値のパース(`ARRAY['a', 'b']`, `{a,b}`, `("a")`形式)
+func parseSqlArray(value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return []string{}
+	}
+	if strings.HasPrefix(strings.ToUpper(value), "ARRAY") {
+		value = strings.TrimSpace(value[len("ARRAY"):])
+	}
+	if len(value) >= 2 && strings.ContainsAny(value[:1], "[{(") {
+		value = value[1 : len(value)-1]
+	}
+
+	items := []string{}
+	var quote byte
+	var item strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == '"' && c == '\\' && i+1 < len(value):
+			i++
+			item.WriteByte(value[i])
+		case c == ',' && quote == 0:
+			items = append(items, strings.TrimSpace(item.String()))
+			item.Reset()
+		default:
+			item.WriteByte(c)
+		}
+	}
+	items = append(items, strings.TrimSpace(item.String()))
+	return items
+}
+
+// FUNCTION: headers列(jsonb)のパース
+func parseSqlHeaders(value string) map[string][]string {
+	headers := map[string][]string{}
+	if value == "" {
+		return headers
+	}
+	var parsed map[string][]string
+	if err := yaml.Unmarshal([]byte(value), &parsed); err == nil {
+		headers = parsed
+	}
+	return headers
+}
+
+// FUNCTION: テーブル名の正規化(引用符・publicスキーマを除去)
+func normalizeTable(name string) string {
+	name = strings.ToLower(strings.NewReplacer(`"`, "", "`", "").Replace(name))
+	return strings.TrimPrefix(name, "public.")
+}
+
+// FUNCTION: 列名の分割
+func splitColumns(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	columns := strings.Split(text, ",")
+	for i, column := range columns {
+		columns[i] = strings.ToLower(strings.Trim(strings.TrimSpace(column), `"`))
+	}
+	return columns
+}
+
+// TITLE: decK形式の構造体
+type deckFile struct {
+	Services []deckService `yaml:"services"`
+	Routes   []deckRoute   `yaml:"routes"`
+}
+
+type deckService struct {
+	Id     string      `yaml:"id"`
+	Name   string      `yaml:"name"`
+	Host   string      `yaml:"host"`
+	Port   int         `yaml:"port"`
+	Tags   []string    `yaml:"tags"`
+	Routes []deckRoute `yaml:"routes"`
+}
+
+type deckRoute struct {
+	Id      string              `yaml:"id"`
+	Name    string              `yaml:"name"`
+	Methods []string            `yaml:"methods"`
+	Paths   []string            `yaml:"paths"`
+	Headers map[string][]string `yaml:"headers"`
+	Tags    []string            `yaml:"tags"`
+	Service struct {
+		Id   string `yaml:"id"`
+		Name string `yaml:"name"`
+	} `yaml:"service"`
+}
+
+// FUNCTION: decK形式ファイルの読込み
+func readDeck(path string) (*KongState, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file: %w", err)
+	}
+	var deck deckFile
+	if err := yaml.Unmarshal(file, &deck); err != nil {
+		return nil, err
+	}
+
+	state := KongState{Services: []KongService{}, Routes: []KongRoute{}}
+	serviceIds := map[string]string{}
+	for _, service := range deck.Services {
+		state.Services = append(state.Services, KongService{Id: service.Id, Name: service.Name, Host: service.Host, Port: service.Port, Tags: service.Tags})
+		serviceIds[service.Name] = service.Id
+		for _, route := range service.Routes {
+			state.Routes = append(state.Routes, route.kongRoute(service.Id))
+		}
+	}
+	// INFO: トップレベルのroutesはservice(idまたはname)で紐づける
+	for _, route := range deck.Routes {
+		serviceId := route.Service.Id
+		if serviceId == "" {
+			serviceId = serviceIds[route.Service.Name]
+		}
+		state.Routes = append(state.Routes, route.kongRoute(serviceId))
+	}
+	return &state, nil
+}
+
+// FUNCTION: KongRouteへの変換
+func (route deckRoute) kongRoute(serviceId string) KongRoute {
+	return KongRoute{
+		Id:        route.Id,
+		Name:      route.Name,
+		ServiceId: serviceId,
+		Methods:   route.Methods,
+		Paths:     route.Paths,
+		Headers:   route.Headers,
+		Tags:      route.Tags,
+	}
+}
+
+// FUNCTION: Serviceの検索
+func (state *KongState) service(id string) (KongService, bool) {
+	for _, service := range state.Services {
+		if service.Id == id {
+			return service, true
+		}
+	}
+	return KongService{}, false
+}
+
+// FUNCTION: Mock用のServiceかどうか(タグまたは名称で判定)
+func (service KongService) isMock() bool {
+	for _, tag := range service.Tags {
+		if tag == "mock" {
+			return true
+		}
+	}
+	return strings.HasSuffix(strings.ToUpper(service.Name), "(MOCK)")
+}
@@ -4,139 +4,61 @@ Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
 package model
 
 import (
-	"fmt"
-	"strconv"
-	"strings"
-
-	"github.com/teru-0529/api-forge/store"
+	"path/filepath"
 )
 
 const PROD = "🟢 production"
 const MOCK = "🟡 mock"
 
+// TITLE: ApiPageDocument構造体(API詳細ページのテンプレートに渡すデータ)
+type ApiPageDocument struct {
+	Service ServiceDocument
+	Api     ApiDocument
+	// INFO: 詳細ページから一覧(api-list.md)への相対パス
+	ListPath string
+}
+
 // FUNCTION: MDファイルの書き込み
+// INFO: 一覧からリンクするAPI詳細ページ(apis/<service>/<operationId>.md)を併せて作成する
 func (apiList *ApiList) ListMd(path string) error {
-	// PROCESS: Fileの取得
-	file, cleanup, err := store.NewFile(path)
-	if err != nil {
+	if err := apiList.renderBuiltin("api-list.md.tmpl", path, false); err != nil {
 		return err
 	}
-	defer cleanup()
-
-	// PROCESS: 書き込み
-	file.WriteString("# API list\n")
-
-	for _, service := range apiList.Services {
-		file.WriteString(fmt.Sprintf("\n## %s(%s)\n\n", service.ServiceName, service.openapi.description))
-
-		file.WriteString("  | ResourceId | Path | Method | Name | ParamNum | RequestBody | Responses | Status |\n")
-		file.WriteString("  |---|---|---|---|--:|---|---|---|\n")
-
-		for _, api := range service.openapi.apis {
-			// ApiKeyの取得
-			apiKey, err := service.getApikey(api.operationId)
-			if err != nil {
-				return err
-			}
-			// Production/Mock
-			status := MOCK
-			if apiKey.Implemented {
-				status = PROD
-			}
-
-			file.WriteString(fmt.Sprintf("  | %s | %s | %s | %s | %d | %s | %s | %s |\n",
-				apiKey.ResourceId,
-				api.path,
-				api.method,
-				fmt.Sprintf("%s(%s)", api.summary, api.operationId),
-				api.request.paramCount,
-				api.request.bodyName(),
-				api.resNames(),
-				status,
-			))
-		}
-	}
-	return nil
+	return apiList.apiPages(filepath.Dir(path), filepath.Base(path))
 }
 
 // FUNCTION: Tsvファイルの書き込み
 func (apiList *ApiList) ListTsv(path string) error {
-	// PROCESS: Writerの取得
-	writer, cleanup, err := store.NewCsvWriter(path)
+	return apiList.renderBuiltin("api-list.tsv.tmpl", path, true)
+}
+
+// FUNCTION: API詳細ページの書き込み
+func (apiList *ApiList) apiPages(dir string, listName string) error {
+	// PROCESS: テンプレートの読込み
+	source, err := apiList.builtinSource("api-detail.md.tmpl")
+	if err != nil {
+		return err
+	}
+	tmpl, err := apiList.parseTemplate("api-detail.md.tmpl", source)
+	if err != nil {
+		return err
+	}
+
+	// PROCESS: 出力用データの作成
+	document, err := apiList.Document()
 	if err != nil {
 		return err
 	}
-	defer cleanup()
 
 	// PROCESS: 書き込み
-	defer writer.Flush() //内部バッファのフラッシュは必須
-	writer.Write([]string{
-		"Service",
-		"Name",
-		"ResourceId",
-		"Path",
-		"Method",
-		"OperationId",
-		"Summary",
-		"ParamNum",
-		"RequestBody",
-		"Responses",
-		"Status",
-	})
-	for _, service := range apiList.Services {
-		for _, api := range service.openapi.apis {
-			// ApiKeyの取得
-			apiKey, err := service.getApikey(api.operationId)
+	for _, service := range document.Services {
+		for _, api := range service.Apis {
+			page := ApiPageDocument{Service: service, Api: api, ListPath: "../../" + listName}
+			err := executeTemplate(tmpl, filepath.Join(dir, filepath.FromSlash(apiPage(service.Name, api.OperationId))), false, page)
 			if err != nil {
 				return err
 			}
-			// Production/Mock
-			status := MOCK
-			if apiKey.Implemented {
-				status = PROD
-			}
-
-			writer.Write([]string{
-				service.ServiceName,
-				service.openapi.description,
-				apiKey.ResourceId,
-				api.path,
-				api.method,
-				api.operationId,
-				api.summary,
-				strconv.Itoa(api.request.paramCount),
-				api.request.bodyName(),
-				api.resNames(),
-				status,
-			})
 		}
 	}
 	return nil
 }
-
-// FUNCTION: response名称(連結)
-func (api *Api) resNames() string {
-	resNames := []string{}
-	for _, res := range api.responses {
-		resNames = append(resNames, res.bodyName())
-	}
-	return strings.Join(resNames, ", ")
-}
-
-// FUNCTION: requeestBody名称
-func (req *Request) bodyName() string {
-	if req.hasBody {
-		return req.name
-	} else {
-		return "N/A"
-	}
-}
-
-// FUNCTION: responseBody名称
-func (res *Response) bodyName() string {
-	if res.status == "default" {
-		return res.status
-	} else {
-		return fmt.Sprintf("%s(%s)", res.status, res.name)
-	}
-}
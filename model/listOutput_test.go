@@ -0,0 +1,46 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// FUNCTION: 一覧からリンクするAPI詳細ページを作成すること
+func TestListMdApiPages(t *testing.T) {
+	apiList := ApiList{Services: []Service{newTestService(t, "orders", documentSpec, "orders.put")}}
+	dir := t.TempDir()
+	if err := apiList.ListMd(filepath.Join(dir, "api-list.md")); err != nil {
+		t.Fatal(err)
+	}
+	list, err := os.ReadFile(filepath.Join(dir, "api-list.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(list), "[受注更新(orders.put)](apis/orders/orders.put.md)") {
+		t.Errorf("api-list.md has no link to the detail page:\n%s", list)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "apis", "orders", "orders.put.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# 受注更新(orders.put)\n",
+		"[API list](../../api-list.md) / orders(受注API)\n",
+		"  | Path | /orders/{orderId} |\n",
+		"  | orderId | path | ✔ |",
+		"X-Request-Id",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("detail page: missing %q in\n%s", want, page)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "apis", "orders", "orders.delete.md")); err != nil {
+		t.Error(err)
+	}
+}
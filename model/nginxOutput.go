@@ -0,0 +1,138 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+// FUNCTION: nginx設定ファイルの書き込み
+// INFO: `http`コンテキストにincludeする想定(例: /etc/nginx/conf.d/)
+func (apiList *ApiList) NginxConf(path string, listen int) error {
+	apiList.warnTrafficIgnored("nginx")
+
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	file.WriteString("# reverse proxy configuration generated by api-forge.\n")
+
+	// PROCESS: upstream
+	for _, service := range apiList.Services {
+		file.WriteString(fmt.Sprintf("\n# %s(%s)\n", service.ServiceName, service.openapi.description))
+		file.WriteString(nginxUpstream(nginxUpstreamName(service.ServiceName, false), service.ProdServer))
+		file.WriteString(nginxUpstream(nginxUpstreamName(service.ServiceName, true), service.MockServer))
+	}
+
+	// PROCESS: server/location
+	file.WriteString("\nserver {\n")
+	file.WriteString(fmt.Sprintf("    listen %d;\n", listen))
+	file.WriteString("    proxy_set_header Host $host;\n")
+	file.WriteString("    proxy_set_header X-Real-IP $remote_addr;\n")
+	file.WriteString("    proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;\n")
+
+	for _, service := range apiList.Services {
+		routes, err := service.routes(apiList.MockHeader)
+		if err != nil {
+			return err
+		}
+		file.WriteString(fmt.Sprintf("\n    # ----+----+----+ %s\n", service.ServiceName))
+
+		// INFO: nginxは正規表現のlocationを記載順に評価するため、優先度の高い順に出力する
+		sort.SliceStable(routes, func(i, j int) bool {
+			return routes[i].Priority > routes[j].Priority
+		})
+		regexes := []string{}
+		locations := map[string][]Route{}
+		for _, route := range routes {
+			if len(route.Headers) > 0 {
+				continue
+			}
+			if _, ok := locations[route.Regex]; !ok {
+				regexes = append(regexes, route.Regex)
+			}
+			locations[route.Regex] = append(locations[route.Regex], route)
+		}
+
+		for _, regex := range regexes {
+			file.WriteString(nginxLocation(service.ServiceName, regex, locations[regex], apiList.MockHeader))
+		}
+	}
+	file.WriteString("}\n")
+
+	return nil
+}
+
+// FUNCTION: upstreamブロック
+func nginxUpstream(name string, server Server) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("upstream %s {\n", name))
+	if len(server.Targets) == 0 {
+		builder.WriteString(fmt.Sprintf("    server %s:%d;\n", server.Host, server.Port))
+	}
+	for _, target := range server.Targets {
+		builder.WriteString(fmt.Sprintf("    server %s weight=%d;\n", target.Target, target.weight()))
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// FUNCTION: locationブロック
+// INFO: メソッド毎に転送先が異なる場合は変数で振り分ける
+func nginxLocation(serviceName string, regex string, routes []Route, mockHeader *MockHeader) string {
+	methods := []string{}
+	upstreams := map[string]bool{}
+	for _, route := range routes {
+		methods = append(methods, route.Method)
+		upstreams[nginxUpstreamName(serviceName, route.IsMock)] = true
+	}
+	mock := nginxUpstreamName(serviceName, true)
+	// INFO: Mock切替ヘッダーはProductionに転送するメソッドがある場合のみ必要
+	switchable := mockHeader != nil && mockHeader.Name != "" && upstreams[nginxUpstreamName(serviceName, false)]
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("\n    location ~ ^%s$ {\n", regex))
+	for _, route := range routes {
+		builder.WriteString(fmt.Sprintf("        # %s %s\n", route.Method, route.Name))
+	}
+	builder.WriteString(fmt.Sprintf("        limit_except %s { deny all; }\n", strings.Join(methods, " ")))
+
+	if len(upstreams) == 1 && !switchable {
+		builder.WriteString(fmt.Sprintf("        proxy_pass http://%s;\n", nginxUpstreamName(serviceName, routes[0].IsMock)))
+	} else {
+		builder.WriteString(fmt.Sprintf("        set $api_upstream %s;\n", mock))
+		for _, route := range routes {
+			if !route.IsMock {
+				builder.WriteString(fmt.Sprintf("        if ($request_method = %s) { set $api_upstream %s; }\n", route.Method, nginxUpstreamName(serviceName, false)))
+			}
+		}
+		if switchable {
+			builder.WriteString(fmt.Sprintf("        if ($%s ~* \"^%s$\") { set $api_upstream %s; }\n", nginxHeaderVar(mockHeader.Name), mockHeader.value(), mock))
+		}
+		builder.WriteString("        proxy_pass http://$api_upstream;\n")
+	}
+	builder.WriteString("    }\n")
+	return builder.String()
+}
+
+// FUNCTION: upstream名称
+func nginxUpstreamName(serviceName string, isMock bool) string {
+	if isMock {
+		return fmt.Sprintf("%s_mock", serviceName)
+	}
+	return fmt.Sprintf("%s_prod", serviceName)
+}
+
+// FUNCTION: リクエストヘッダーの変数名
+func nginxHeaderVar(name string) string {
+	return "http_" + strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
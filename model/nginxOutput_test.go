@@ -0,0 +1,55 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// FUNCTION: 優先度の高い順にlocationを出力し、メソッド・ヘッダーで転送先を振り分けること
+func TestNginxConf(t *testing.T) {
+	apiList := ApiList{
+		MockHeader: &MockHeader{Name: "X-Mock"},
+		Services: []Service{
+			newTestService(t, "sample", conflictSpec),
+			newTestService(t, "orders", samePathSpec, "receivings.post"),
+		},
+	}
+	path := filepath.Join(t.TempDir(), "nginx.conf")
+	if err := apiList.NginxConf(path, 8000); err != nil {
+		t.Fatal(err)
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := string(source)
+
+	// PROCESS: 固定文字列のlocationはパラメータのlocationより先に評価される
+	search := strings.Index(conf, `location ~ ^/sample/items/search$`)
+	param := strings.Index(conf, `location ~ ^/sample/items/`+PARAM_REGEX+`$`)
+	if search < 0 || param < 0 || search > param {
+		t.Errorf("location order: search=%d, param=%d", search, param)
+	}
+
+	// PROCESS: 全てMockのlocationはヘッダーで切り替えない
+	if !strings.Contains(conf, "        proxy_pass http://sample_mock;\n") {
+		t.Error("mock-only location must proxy to the mock upstream")
+	}
+
+	// PROCESS: メソッド・ヘッダーでの振り分け
+	for _, want := range []string{
+		"        limit_except GET POST { deny all; }\n",
+		"        if ($request_method = POST) { set $api_upstream orders_prod; }\n",
+		"        if ($http_x_mock ~* \"^true$\") { set $api_upstream orders_mock; }\n",
+		"upstream orders_prod {\n    server orders:8080;\n}\n",
+	} {
+		if !strings.Contains(conf, want) {
+			t.Errorf("missing %q in\n%s", want, conf)
+		}
+	}
+}
@@ -4,14 +4,30 @@ Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
 package model
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/koron/go-dproxy"
 	"gopkg.in/yaml.v3"
 )
 
+// HTTPメソッド
+var httpMethods = map[string]bool{
+	"get":     true,
+	"put":     true,
+	"post":    true,
+	"delete":  true,
+	"options": true,
+	"head":    true,
+	"patch":   true,
+	"trace":   true,
+}
+
 // TITLE: Openapi構造体
 type Openapi struct {
 	formatVersion string
@@ -19,6 +35,12 @@ type Openapi struct {
 	description   string
 	version       string
 	apis          []Api
+	tags          []Tag
+	security      []SecurityRequirement
+	schemes       map[string]SecurityScheme
+	rateLimit     RateLimit
+	cors          Cors
+	hash          string
 }
 
 type Api struct {
@@ -29,27 +51,103 @@ type Api struct {
 	description string
 	request     Request
 	responses   []Response
+	security    []SecurityRequirement
+	hasSecurity bool
+	rateLimit   RateLimit
+	cors        Cors
+	healthCheck bool
+	roles       []string
+	tags        []string
+	deprecated  bool
+}
+
+type Tag struct {
+	name        string
+	description string
 }
 
 type Request struct {
-	paramCount int
-	hasBody    bool
-	name       string
+	paramCount  int
+	hasBody     bool
+	name        string
+	parameters  []Parameter
+	required    bool
+	contentType string
+	schema      string
+	fields      []Field
+	example     interface{}
+}
+
+type Parameter struct {
+	name        string
+	in          string
+	required    bool
+	description string
+	schema      string
+	kind        string
+	enum        []string
+	example     interface{}
 }
 
 type Response struct {
-	status string
-	name   string
+	status      string
+	name        string
+	contentType string
+	schema      string
+	headers     []Header
+	fields      []Field
+	example     interface{}
+}
+
+// INFO: レスポンスヘッダー
+type Header struct {
+	name        string
+	required    bool
+	description string
+	kind        string
+	example     interface{}
+}
+
+// INFO: ボディのスキーマ(object)の項目、配列の場合は要素の項目(fieldsはobjectの項目の子項目)
+type Field struct {
+	name        string
+	required    bool
+	description string
+	kind        string
+	enum        []string
+	fields      []Field
+}
+
+// INFO: スキーム名とスコープの組(いずれかを満たせばよい要件の1つ)
+type SecurityRequirement map[string][]string
+
+type SecurityScheme struct {
+	kind         string
+	scheme       string
+	bearerFormat string
+	name         string
+	in           string
 }
 
 // FUNCTION: Apiパース
 func NewOpenapi(service Service) (*Openapi, error) {
 	log.Printf("parse '%s' openapi file.", service.ServiceName)
-	openapi := Openapi{}
 
 	// PROCESS: openapi.yamlの読込み
-	row, err := readOpenapi(service.OpenapiPath)
+	source, err := os.ReadFile(service.OpenapiPath)
 	if err != nil {
+		return nil, fmt.Errorf("cannot read file: %w", err)
+	}
+	return parseOpenapi(source)
+}
+
+// FUNCTION: Apiパース(ファイルの内容から、git上の過去バージョンの比較にも使う)
+func parseOpenapi(source []byte) (*Openapi, error) {
+	openapi := Openapi{hash: fmt.Sprintf("%x", sha256.Sum256(source))}
+
+	// PROCESS: パース
+	var row interface{}
+	if err := yaml.Unmarshal(source, &row); err != nil {
 		return nil, err
 	}
 
@@ -68,12 +166,45 @@ func NewOpenapi(service Service) (*Openapi, error) {
 	version, _ := info.M("version").String()
 	openapi.version = version
 
+	// PROCESS: transfer(tags)
+	tags, _ := proxy.M("tags").Array()
+	for _, item := range tags {
+		p := dproxy.New(item)
+		name, _ := p.M("name").String()
+		description, _ := p.M("description").String()
+		openapi.tags = append(openapi.tags, Tag{name: name, description: description})
+	}
+
+	// PROCESS: transfer(security)
+	openapi.security, _ = securityRequirements(proxy.M("security"))
+	openapi.schemes = map[string]SecurityScheme{}
+	schemes, _ := proxy.M("components").M("securitySchemes").Map()
+	for name, item := range schemes {
+		p := dproxy.New(item)
+		kind, _ := p.M("type").String()
+		scheme, _ := p.M("scheme").String()
+		bearerFormat, _ := p.M("bearerFormat").String()
+		keyName, _ := p.M("name").String()
+		in, _ := p.M("in").String()
+		openapi.schemes[name] = SecurityScheme{kind: kind, scheme: scheme, bearerFormat: bearerFormat, name: keyName, in: in}
+	}
+
+	// PROCESS: transfer(traffic control)
+	openapi.rateLimit = newRateLimit(proxy.M("x-rate-limit"))
+	openapi.cors = newCors(proxy.M("x-cors"))
+
 	// PROCESS: transfer(path)
 	apis, _ := proxy.M("paths").Map()
 	ls := []Api{}
-	for path, pathItem := range apis {
-		items, _ := dproxy.New(pathItem).Map()
-		for method, item := range items {
+	for _, path := range sortedKeys(apis) {
+		pathProxy := dproxy.New(apis[path])
+		items, _ := pathProxy.Map()
+		for _, method := range sortedKeys(items) {
+			// INFO: メソッド以外の要素(parameters, x-拡張など)は対象外
+			if !httpMethods[method] {
+				continue
+			}
+			item := items[method]
 			api := Api{path: path, method: method}
 			p := dproxy.New(item)
 
@@ -83,54 +214,408 @@ func NewOpenapi(service Service) (*Openapi, error) {
 			api.summary = summary
 			description, _ := p.M("description").String()
 			api.description = description
+			healthCheck, _ := p.M("x-health-check").Bool()
+			api.healthCheck = healthCheck
+			api.tags = stringArray(p.M("tags"))
 
 			// PROCESS: request
-			// INFO: リクエストパラメータ(num)
-			paramCount := p.Q("parameters").Len()
+			// INFO: リクエストパラメータ(path > operation、`$ref`は参照先を展開する)
+			parameters := newParameters(proxy, pathProxy.M("parameters"), p.M("parameters"))
 
 			hasBody := false
 			name := ""
 			// INFO: リクエストボディ(has,description)
-			bodyDescription, err := p.M("requestBody").M("description").String()
+			body := resolveRef(proxy, p.M("requestBody"))
+			bodyDescription, err := body.M("description").String()
 			if err == nil {
 				hasBody = true
 				name = bodyDescription
 			}
-			api.request = Request{paramCount: paramCount, hasBody: hasBody, name: name}
+			required, _ := body.M("required").Bool()
+			contentType, schema := contentSchema(body.M("content"))
+			media := body.M("content").M(contentType)
+			api.request = Request{
+				paramCount:  len(parameters),
+				hasBody:     hasBody,
+				name:        name,
+				parameters:  parameters,
+				required:    required,
+				contentType: contentType,
+				schema:      schema,
+				fields:      schemaFields(proxy, media.M("schema"), true),
+				example:     mediaExample(proxy, media),
+			}
 
 			// PROCESS: response
 			// INFO: レスポンス(status,description)
 			ress := []Response{}
 			res, _ := p.M("responses").Map()
-			for status, resItem := range res {
-				description, _ := dproxy.New(resItem).M("description").String()
+			for _, status := range sortedKeys(res) {
+				response := resolveRef(proxy, dproxy.New(res[status]))
+				description, _ := response.M("description").String()
+				contentType, schema := contentSchema(response.M("content"))
+				media := response.M("content").M(contentType)
 
-				ress = append(ress, Response{status: status, name: description})
+				ress = append(ress, Response{
+					status:      status,
+					name:        description,
+					contentType: contentType,
+					schema:      schema,
+					headers:     newHeaders(proxy, response.M("headers")),
+					fields:      schemaFields(proxy, media.M("schema"), false),
+					example:     mediaExample(proxy, media),
+				})
 			}
 			api.responses = ress
 
+			// PROCESS: security
+			// INFO: `security: []`の場合は公開APIとして扱うため、宣言有無を区別する
+			api.security, api.hasSecurity = securityRequirements(p.M("security"))
+
+			// PROCESS: traffic control(operation > path)
+			api.rateLimit = newRateLimit(pathProxy.M("x-rate-limit")).merge(newRateLimit(p.M("x-rate-limit")))
+			api.cors = newCors(pathProxy.M("x-cors")).merge(newCors(p.M("x-cors")))
+
+			// PROCESS: roles(path + operation)
+			api.roles = uniqueSorted(append(stringArray(pathProxy.M("x-roles")), stringArray(p.M("x-roles"))...))
+
+			// PROCESS: lifecycle
+			api.deprecated, _ = p.M("deprecated").Bool()
+
 			ls = append(ls, api)
 		}
 	}
 	openapi.apis = ls
 
+	// PROCESS: 未宣言のタグ(operationのみで使用)
+	for _, api := range openapi.apis {
+		for _, name := range api.tags {
+			if _, ok := openapi.tag(name); !ok {
+				openapi.tags = append(openapi.tags, Tag{name: name})
+			}
+		}
+	}
+
 	return &openapi, nil
 }
 
-// FUNCTION: Apiファイル読込み
-func readOpenapi(path string) (interface{}, error) {
+// FUNCTION: パラメータのパース
+// INFO: 同じin/nameのパラメータはoperationの定義を優先する
+func newParameters(root dproxy.Proxy, pathParams dproxy.Proxy, operationParams dproxy.Proxy) []Parameter {
+	parameters := []Parameter{}
+	for _, params := range []dproxy.Proxy{pathParams, operationParams} {
+		items, _ := params.Array()
+		for _, item := range items {
+			p := dproxy.New(item)
+			resolved := resolveRef(root, p)
+			name, _ := resolved.M("name").String()
+			in, _ := resolved.M("in").String()
+			required, _ := resolved.M("required").Bool()
+			// INFO: `$ref`と並べて記述したdescriptionは参照先より優先する
+			description, err := p.M("description").String()
+			if err != nil {
+				description, _ = resolved.M("description").String()
+			}
+			parameter := Parameter{
+				name:        name,
+				in:          in,
+				required:    required,
+				description: description,
+				schema:      schemaName(resolved.M("schema")),
+				kind:        schemaType(root, resolved.M("schema")),
+				enum:        schemaEnum(root, resolved.M("schema")),
+				example:     example(root, p, resolved, resolved.M("schema")),
+			}
 
-	// PROCESS: openapi.yamlの読込み
-	file, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("cannot read file: %w", err)
+			replaced := false
+			for i, exist := range parameters {
+				if exist.name == name && exist.in == in {
+					parameters[i] = parameter
+					replaced = true
+				}
+			}
+			if !replaced {
+				parameters = append(parameters, parameter)
+			}
+		}
+	}
+	return parameters
+}
+
+// FUNCTION: `$ref`の参照先(ドキュメント内の参照のみ)
+func resolveRef(root dproxy.Proxy, p dproxy.Proxy) dproxy.Proxy {
+	for range 8 {
+		ref, err := p.M("$ref").String()
+		if err != nil || !strings.HasPrefix(ref, "#/") {
+			return p
+		}
+		p = root
+		for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			p = p.M(strings.NewReplacer("~1", "/", "~0", "~").Replace(key))
+		}
 	}
+	return p
+}
 
-	// PROCESS: パース
-	var rowApi interface{}
-	err = yaml.Unmarshal([]byte(file), &rowApi)
+// FUNCTION: contentのメディアタイプとスキーマ名(複数の場合は先頭)
+func contentSchema(p dproxy.Proxy) (string, string) {
+	content, err := p.Map()
+	if err != nil || len(content) == 0 {
+		return "", ""
+	}
+	contentType := sortedKeys(content)[0]
+	return contentType, schemaName(p.M(contentType).M("schema"))
+}
+
+// 子項目を展開する階層
+const SCHEMA_NEST_DEPTH = 8
+
+// INFO: スキーマの合成(`allOf`は全ての要素、`oneOf`/`anyOf`はいずれかの要素)
+var schemaCompositions = []struct {
+	key       string
+	separator string
+}{{"allOf", " & "}, {"oneOf", " | "}, {"anyOf", " | "}}
+
+// FUNCTION: スキーマの表示名(参照名、配列は`[]`付き、合成は要素の表示名、それ以外は型)
+func schemaName(p dproxy.Proxy) string {
+	if ref, err := p.M("$ref").String(); err == nil {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	kind, _ := p.M("type").String()
+	if kind == "array" {
+		return schemaName(p.M("items")) + "[]"
+	}
+	if kind == "" {
+		for _, composition := range schemaCompositions {
+			items, _ := p.M(composition.key).Array()
+			names := []string{}
+			for i := range items {
+				names = append(names, schemaName(p.M(composition.key).A(i)))
+			}
+			if len(names) > 0 {
+				return strings.Join(names, composition.separator)
+			}
+		}
+	}
+	return kind
+}
+
+// FUNCTION: スキーマの展開(`$ref`の参照先、要素が1つの`allOf`/`oneOf`/`anyOf`はその要素)
+func resolveSchema(root dproxy.Proxy, p dproxy.Proxy) dproxy.Proxy {
+	for range 8 {
+		p = resolveRef(root, p)
+		if _, err := p.M("type").String(); err == nil {
+			return p
+		}
+		var single dproxy.Proxy
+		for _, composition := range schemaCompositions {
+			if items, _ := p.M(composition.key).Array(); len(items) == 1 {
+				single = p.M(composition.key).A(0)
+			}
+		}
+		if single == nil {
+			return p
+		}
+		p = single
+	}
+	return p
+}
+
+// FUNCTION: スキーマの型(参照先・合成を展開する、formatがある場合は併記、objectは参照名)
+func schemaType(root dproxy.Proxy, p dproxy.Proxy) string {
+	resolved := resolveSchema(root, p)
+	kind, _ := resolved.M("type").String()
+	switch kind {
+	case "array":
+		return schemaType(root, resolved.M("items")) + "[]"
+	case "object", "":
+		if name := schemaName(p); name != "" {
+			return name
+		}
+		return kind
+	}
+	if format, err := resolved.M("format").String(); err == nil {
+		return fmt.Sprintf("%s(%s)", kind, format)
+	}
+	return kind
+}
+
+// FUNCTION: スキーマの列挙値(配列は要素の列挙値)
+func schemaEnum(root dproxy.Proxy, p dproxy.Proxy) []string {
+	resolved := resolveSchema(root, p)
+	if kind, _ := resolved.M("type").String(); kind == "array" {
+		return schemaEnum(root, resolved.M("items"))
+	}
+	items, _ := resolved.M("enum").Array()
+	values := []string{}
+	for _, item := range items {
+		values = append(values, fmt.Sprint(item))
+	}
+	return values
+}
+
+// FUNCTION: スキーマの項目(`$ref`と並べて記述したdescriptionは参照先より優先する)
+// INFO: リクエストはreadOnly、レスポンスはwriteOnlyの項目を除く
+func schemaFields(root dproxy.Proxy, p dproxy.Proxy, request bool) []Field {
+	return nestedSchemaFields(root, p, request, SCHEMA_NEST_DEPTH)
+}
+
+// FUNCTION: スキーマの項目(子項目は指定した階層まで、循環参照で無限に展開しないよう制限する)
+func nestedSchemaFields(root dproxy.Proxy, p dproxy.Proxy, request bool, depth int) []Field {
+	if depth == 0 {
+		return []Field{}
+	}
+	resolved := resolveSchema(root, p)
+	if kind, _ := resolved.M("type").String(); kind == "array" {
+		resolved = resolveSchema(root, resolved.M("items"))
+	}
+	properties, required := schemaProperties(root, resolved)
+	skip := "writeOnly"
+	if request {
+		skip = "readOnly"
+	}
+
+	fields := []Field{}
+	for _, name := range sortedKeys(properties) {
+		property := properties[name]
+		if schemaFlag(root, property, skip) {
+			continue
+		}
+		description, err := property.M("description").String()
+		if err != nil {
+			description, _ = resolveSchema(root, property).M("description").String()
+		}
+		fields = append(fields, Field{
+			name:        name,
+			required:    slices.Contains(required, name),
+			description: description,
+			kind:        schemaType(root, property),
+			enum:        schemaEnum(root, property),
+			fields:      nestedSchemaFields(root, property, request, depth-1),
+		})
+	}
+	return fields
+}
+
+// FUNCTION: スキーマのプロパティと必須項目(`allOf`の要素のプロパティを含む)
+func schemaProperties(root dproxy.Proxy, resolved dproxy.Proxy) (map[string]dproxy.Proxy, []string) {
+	properties := map[string]dproxy.Proxy{}
+	required := stringArray(resolved.M("required"))
+	items, _ := resolved.M("allOf").Array()
+	for i := range items {
+		nested, nestedRequired := schemaProperties(root, resolveSchema(root, resolved.M("allOf").A(i)))
+		for name, property := range nested {
+			properties[name] = property
+		}
+		required = append(required, nestedRequired...)
+	}
+	names, _ := resolved.M("properties").Map()
+	for name := range names {
+		properties[name] = resolved.M("properties").M(name)
+	}
+	return properties, required
+}
+
+// FUNCTION: スキーマの真偽値の属性(プロパティ自身または参照先・合成の展開先)
+func schemaFlag(root dproxy.Proxy, p dproxy.Proxy, name string) bool {
+	for _, item := range []dproxy.Proxy{p, resolveSchema(root, p)} {
+		if value, _ := item.M(name).Bool(); value {
+			return true
+		}
+	}
+	return false
+}
+
+// FUNCTION: ヘッダーのパース
+func newHeaders(root dproxy.Proxy, p dproxy.Proxy) []Header {
+	items, _ := p.Map()
+	headers := []Header{}
+	for _, name := range sortedKeys(items) {
+		header := p.M(name)
+		resolved := resolveRef(root, header)
+		required, _ := resolved.M("required").Bool()
+		description, err := header.M("description").String()
+		if err != nil {
+			description, _ = resolved.M("description").String()
+		}
+		headers = append(headers, Header{
+			name:        name,
+			required:    required,
+			description: description,
+			kind:        schemaType(root, resolved.M("schema")),
+			example:     example(root, header, resolved, resolved.M("schema")),
+		})
+	}
+	return headers
+}
+
+// FUNCTION: メディアタイプの例(example > examplesの先頭 > スキーマのexample)
+func mediaExample(root dproxy.Proxy, media dproxy.Proxy) interface{} {
+	if value, err := media.M("example").Value(); err == nil {
+		return value
+	}
+	examples, _ := media.M("examples").Map()
+	if len(examples) > 0 {
+		first := resolveRef(root, media.M("examples").M(sortedKeys(examples)[0]))
+		if value, err := first.M("value").Value(); err == nil {
+			return value
+		}
+	}
+	return example(root, media.M("schema"))
+}
+
+// FUNCTION: 例(指定順に探し、`$ref`は参照先も探す)
+func example(root dproxy.Proxy, candidates ...dproxy.Proxy) interface{} {
+	for _, p := range candidates {
+		for _, item := range []dproxy.Proxy{p, resolveRef(root, p)} {
+			if value, err := item.M("example").Value(); err == nil {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+// FUNCTION: タグの取得
+func (openapi *Openapi) tag(name string) (Tag, bool) {
+	for _, tag := range openapi.tags {
+		if tag.name == name {
+			return tag, true
+		}
+	}
+	return Tag{}, false
+}
+
+// FUNCTION: security要件のパース
+func securityRequirements(p dproxy.Proxy) ([]SecurityRequirement, bool) {
+	items, err := p.Array()
 	if err != nil {
-		return nil, err
+		return nil, false
+	}
+	requirements := []SecurityRequirement{}
+	for _, item := range items {
+		schemes, _ := dproxy.New(item).Map()
+		requirement := SecurityRequirement{}
+		for name, scopes := range schemes {
+			requirement[name] = []string{}
+			ls, _ := dproxy.New(scopes).Array()
+			for _, scope := range ls {
+				if str, ok := scope.(string); ok {
+					requirement[name] = append(requirement[name], str)
+				}
+			}
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, true
+}
+
+// FUNCTION: mapのキーを昇順で取得(出力順を安定させる)
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
-	return rowApi, nil
+	sort.Strings(keys)
+	return keys
 }
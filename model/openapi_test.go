@@ -0,0 +1,87 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"testing"
+)
+
+// 合成(allOf)とreadOnlyを含むスキーマ
+const composedSpec = `
+openapi: 3.0.3
+info:
+  title: Composed
+  version: 1.0.0
+paths:
+  /items:
+    put:
+      operationId: items.put
+      requestBody:
+        description: item
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/item'
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/item'
+components:
+  schemas:
+    price:
+      type: integer
+      format: int32
+    base:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+    item:
+      allOf:
+        - $ref: '#/components/schemas/base'
+        - type: object
+          properties:
+            price:
+              $ref: '#/components/schemas/price'
+            costPrice:
+              description: calculated
+              readOnly: true
+              allOf:
+                - $ref: '#/components/schemas/price'
+`
+
+// FUNCTION: allOfを展開し、リクエストではreadOnlyの項目を除くこと
+func TestSchemaFieldsComposition(t *testing.T) {
+	openapi, err := parseOpenapi([]byte(composedSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := openapi.apis[0]
+
+	// PROCESS: リクエスト
+	got := map[string]Field{}
+	for _, field := range api.request.fields {
+		got[field.name] = field
+	}
+	if _, ok := got["costPrice"]; ok || len(got) != 2 {
+		t.Errorf("request fields = %v, want name/price", api.request.fields)
+	}
+	if !got["name"].required {
+		t.Error("name must be required (allOf)")
+	}
+
+	// PROCESS: レスポンス
+	for _, field := range api.responses[0].fields {
+		if field.name == "costPrice" && (field.kind != "integer(int32)" || field.description != "calculated") {
+			t.Errorf("costPrice = %+v, want integer(int32)", field)
+		}
+	}
+	if len(api.responses[0].fields) != 3 {
+		t.Errorf("response fields = %v, want 3 fields", api.responses[0].fields)
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// TITLE: KongPlugin構造体
+type KongPlugin struct {
+	Id        string
+	Name      string
+	ServiceId string
+	RouteId   string
+	Config    map[string]interface{}
+}
+
+// FUNCTION: Serviceに関するPluginの作成
+// INFO: Route単位のPluginは、同じAPIの全Route(ヘッダー切替用のMock Routeを含む)に紐づける
+func (service *Service) plugins(routes []Route, anonymous string) ([]KongPlugin, error) {
+	plugins, err := service.securityPlugins(routes, anonymous)
+	if err != nil {
+		return nil, err
+	}
+	return append(plugins, service.trafficPlugins(routes)...), nil
+}
+
+// FUNCTION: securitySchemesから認証系Pluginを作成
+// INFO: ルートのsecurityはServiceに紐づけ、securityを上書きするAPIのみRouteに紐づける
+// INFO: Route単位のPluginは同名のService単位のPluginより優先されるため、同名が無いPluginは`anonymous`付きのPluginで無効化する
+func (service *Service) securityPlugins(routes []Route, anonymous string) ([]KongPlugin, error) {
+	openapi := service.openapi
+	servicePlugins, err := openapi.authPlugins(openapi.security)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", service.ServiceName, err)
+	}
+
+	// PROCESS: securityを上書きするAPIのPlugin
+	routePlugins := map[string][]KongPlugin{}
+	perRoute := false
+	for _, route := range routes {
+		if !route.api.hasSecurity {
+			continue
+		}
+		items, err := openapi.authPlugins(route.api.security)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", route.api.operationId, err)
+		}
+		for _, plugin := range servicePlugins {
+			if slices.ContainsFunc(items, func(item KongPlugin) bool { return item.Name == plugin.Name }) {
+				continue
+			}
+			exempt, ok := plugin.anonymousOf(anonymous)
+			if !ok {
+				// INFO: 無効化できない場合は、全Routeに紐づける(Service単位のPluginは作成しない)
+				log.Printf("WARNING: '%s' cannot disable service-level plugin '%s' (set 'anonymousConsumer'). auth plugins of '%s' are attached to every route.",
+					route.api.operationId, plugin.Name, service.ServiceName)
+				perRoute = true
+				break
+			}
+			items = append(items, exempt)
+		}
+		if perRoute {
+			break
+		}
+		routePlugins[route.Id] = items
+	}
+
+	plugins := []KongPlugin{}
+	if perRoute {
+		for _, route := range routes {
+			// INFO: `security: []`は公開API
+			requirements := openapi.security
+			if route.api.hasSecurity {
+				requirements = route.api.security
+			}
+			items, err := openapi.authPlugins(requirements)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", route.api.operationId, err)
+			}
+			plugins = append(plugins, routeScoped(route, items)...)
+		}
+		return plugins, nil
+	}
+
+	// PROCESS: Service単位
+	for _, server := range []Server{service.ProdServer, service.MockServer} {
+		for _, plugin := range servicePlugins {
+			plugin.Id = deriveId(server.ServiceId, plugin.Name)
+			plugin.ServiceId = server.ServiceId
+			plugins = append(plugins, plugin)
+		}
+	}
+	// PROCESS: Route単位(上書きするAPIのみ)
+	for _, route := range routes {
+		plugins = append(plugins, routeScoped(route, routePlugins[route.Id])...)
+	}
+	return plugins, nil
+}
+
+// FUNCTION: Route単位のPlugin
+func routeScoped(route Route, plugins []KongPlugin) []KongPlugin {
+	scoped := []KongPlugin{}
+	for _, plugin := range plugins {
+		plugin.Id = deriveId(route.Id, plugin.Name)
+		plugin.RouteId = route.Id
+		scoped = append(scoped, plugin)
+	}
+	return scoped
+}
+
+// FUNCTION: 匿名Consumerで通過させる同名のPlugin(認証系のみ)
+func (plugin KongPlugin) anonymousOf(anonymous string) (KongPlugin, bool) {
+	if anonymous == "" || plugin.Name == "acl" {
+		return KongPlugin{}, false
+	}
+	config := map[string]interface{}{}
+	for key, value := range plugin.Config {
+		config[key] = value
+	}
+	config["anonymous"] = anonymous
+	return KongPlugin{Name: plugin.Name, Config: config}, true
+}
+
+// FUNCTION: security要件に対応するPlugin
+// INFO: 複数の要件(いずれかを満たせばよい)はKongのPluginでは全て適用(AND)となるため、エラーとする
+// INFO: スコープはoauth2/openIdConnectのスキームのみACLのグループとして扱う
+func (openapi *Openapi) authPlugins(requirements []SecurityRequirement) ([]KongPlugin, error) {
+	if len(requirements) > 1 {
+		return nil, fmt.Errorf("security alternatives(%d requirements) cannot be expressed by kong plugins, declare a single requirement", len(requirements))
+	}
+	plugins := []KongPlugin{}
+	scopes := []string{}
+
+	for _, requirement := range requirements {
+		names := []string{}
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			scheme, ok := openapi.schemes[name]
+			if !ok {
+				log.Printf("WARNING: security scheme '%s' is not defined.", name)
+				continue
+			}
+			if scheme.kind == "oauth2" || scheme.kind == "openIdConnect" {
+				scopes = append(scopes, requirement[name]...)
+			}
+			plugin, ok := scheme.plugin()
+			if !ok {
+				log.Printf("WARNING: security scheme '%s'(%s) is not supported.", name, scheme.kind)
+				continue
+			}
+			if !slices.ContainsFunc(plugins, func(item KongPlugin) bool { return item.Name == plugin.Name }) {
+				plugins = append(plugins, plugin)
+			}
+		}
+	}
+
+	// PROCESS: スコープをACLのグループとして扱う
+	if len(scopes) > 0 {
+		plugins = append(plugins, KongPlugin{
+			Name: "acl",
+			Config: map[string]interface{}{
+				"allow":                   uniqueSorted(scopes),
+				"deny":                    nil,
+				"hide_groups_header":      false,
+				"include_consumer_groups": false,
+			},
+		})
+	}
+	return plugins, nil
+}
+
+// FUNCTION: securitySchemeに対応するPlugin
+func (scheme SecurityScheme) plugin() (KongPlugin, bool) {
+	switch {
+	case scheme.kind == "http" && strings.EqualFold(scheme.scheme, "bearer"):
+		return KongPlugin{
+			Name: "jwt",
+			Config: map[string]interface{}{
+				"uri_param_names":    []string{"jwt"},
+				"cookie_names":       []string{},
+				"header_names":       []string{"authorization"},
+				"key_claim_name":     "iss",
+				"secret_is_base64":   false,
+				"claims_to_verify":   []string{"exp"},
+				"anonymous":          nil,
+				"run_on_preflight":   true,
+				"maximum_expiration": 0,
+			},
+		}, true
+	case scheme.kind == "http" && strings.EqualFold(scheme.scheme, "basic"):
+		return KongPlugin{
+			Name: "basic-auth",
+			Config: map[string]interface{}{
+				"anonymous":        nil,
+				"hide_credentials": false,
+			},
+		}, true
+	case scheme.kind == "apiKey" && scheme.in != "cookie":
+		return KongPlugin{
+			Name: "key-auth",
+			Config: map[string]interface{}{
+				"key_names":        []string{scheme.name},
+				"key_in_header":    scheme.in == "header",
+				"key_in_query":     scheme.in == "query",
+				"key_in_body":      false,
+				"hide_credentials": false,
+				"anonymous":        nil,
+				"run_on_preflight": true,
+			},
+		}, true
+	default:
+		return KongPlugin{}, false
+	}
+}
+
+// FUNCTION: configのJSON文字列
+func (plugin KongPlugin) configJson() string {
+	config, _ := json.Marshal(plugin.Config)
+	return string(config)
+}
+
+// FUNCTION: 重複排除・昇順
+func uniqueSorted(values []string) []string {
+	set := map[string]bool{}
+	result := []string{}
+	for _, value := range values {
+		if !set[value] {
+			set[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,40 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"testing"
+)
+
+// FUNCTION: security要件からPluginを作成すること(代替要件はエラー、スコープはoauth2のみACL)
+func TestAuthPlugins(t *testing.T) {
+	openapi := Openapi{schemes: map[string]SecurityScheme{
+		"bearer": {kind: "http", scheme: "bearer"},
+		"apiKey": {kind: "apiKey", name: "x-api-key", in: "header"},
+		"oauth":  {kind: "oauth2"},
+	}}
+
+	// PROCESS: 代替要件
+	if _, err := openapi.authPlugins([]SecurityRequirement{{"bearer": nil}, {"apiKey": nil}}); err == nil {
+		t.Error("alternatives must be rejected")
+	}
+
+	// PROCESS: jwtのスコープはACLにしない
+	plugins, err := openapi.authPlugins([]SecurityRequirement{{"bearer": {"admin"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 1 || plugins[0].Name != "jwt" {
+		t.Errorf("plugins = %v, want [jwt]", plugins)
+	}
+
+	// PROCESS: oauth2のスコープはACLにする
+	plugins, err = openapi.authPlugins([]SecurityRequirement{{"oauth": {"read"}, "apiKey": nil}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 2 || plugins[1].Name != "acl" {
+		t.Errorf("plugins = %v, want [key-auth acl]", plugins)
+	}
+}
@@ -0,0 +1,291 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+const (
+	PROGRESS_BAR_WIDTH     = 20
+	PROGRESS_TREND_RECENTS = 12
+)
+
+// TITLE: Progress構造体(実装進捗の集計結果、JSON出力/履歴の単位)
+type Progress struct {
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Total       ProgressCount     `json:"total"`
+	Services    []ServiceProgress `json:"services"`
+}
+
+type ServiceProgress struct {
+	Name  string        `json:"name"`
+	Title string        `json:"title"`
+	Count ProgressCount `json:"count"`
+}
+
+// INFO: Production/MockはApiKey.Implemented、Active/Deprecatedはopenapiのdeprecatedによる
+type ProgressCount struct {
+	Apis       int     `json:"apis"`
+	Production int     `json:"production"`
+	Mock       int     `json:"mock"`
+	Active     int     `json:"active"`
+	Deprecated int     `json:"deprecated"`
+	Rate       float64 `json:"rate"`
+}
+
+// FUNCTION: 実装進捗の集計
+func (apiList *ApiList) Progress(generatedAt time.Time) (*Progress, error) {
+	progress := Progress{GeneratedAt: generatedAt, Services: []ServiceProgress{}}
+	for _, service := range apiList.Services {
+		count := ProgressCount{}
+		for _, api := range service.openapi.apis {
+			apiKey, err := service.getApikey(api.operationId)
+			if err != nil {
+				return nil, err
+			}
+			count.add(apiKey.Implemented, api.deprecated)
+			progress.Total.add(apiKey.Implemented, api.deprecated)
+		}
+		count.Rate = count.rate()
+		progress.Services = append(progress.Services, ServiceProgress{
+			Name:  service.ServiceName,
+			Title: service.openapi.description,
+			Count: count,
+		})
+	}
+	progress.Total.Rate = progress.Total.rate()
+	return &progress, nil
+}
+
+// FUNCTION: 件数の加算
+func (count *ProgressCount) add(implemented bool, deprecated bool) {
+	count.Apis++
+	if implemented {
+		count.Production++
+	} else {
+		count.Mock++
+	}
+	if deprecated {
+		count.Deprecated++
+	} else {
+		count.Active++
+	}
+}
+
+// FUNCTION: 実装率(%、小数1桁)
+func (count ProgressCount) rate() float64 {
+	if count.Apis == 0 {
+		return 0
+	}
+	return math.Round(float64(count.Production)*1000/float64(count.Apis)) / 10
+}
+
+// FUNCTION: 進捗バー(MD用)
+func (count ProgressCount) bar() string {
+	filled := 0
+	if count.Apis > 0 {
+		filled = count.Production * PROGRESS_BAR_WIDTH / count.Apis
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", PROGRESS_BAR_WIDTH-filled)
+}
+
+// FUNCTION: 履歴ファイルの読込み(存在しない場合は空)
+func LoadProgressHistory(path string) ([]Progress, error) {
+	history := []Progress{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot read file: %w", err)
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("cannot parse history file: %w", err)
+	}
+	return history, nil
+}
+
+// FUNCTION: 履歴ファイルの書き込み
+func SaveProgressHistory(path string, history []Progress) error {
+	return writeProgressJson(path, history)
+}
+
+// FUNCTION: JSONファイルの書き込み
+func (progress *Progress) Json(path string) error {
+	return writeProgressJson(path, progress)
+}
+
+// FUNCTION: JSONの書き込み(共通)
+func writeProgressJson(path string, value any) error {
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(value)
+}
+
+// FUNCTION: MDファイルの書き込み
+// INFO: historyには今回の集計を含めて渡す(2件以上ある場合に推移を出力する)
+func (progress *Progress) Md(path string, badgeDir string, history []Progress) error {
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: バッジの相対パス
+	badgePath, err := filepath.Rel(filepath.Dir(path), badgeDir)
+	if err != nil {
+		return err
+	}
+	badgePath = filepath.ToSlash(badgePath)
+
+	// PROCESS: 書き込み(サマリー)
+	file.WriteString("# Implementation progress\n\n")
+	file.WriteString(fmt.Sprintf("generated at %s\n\n", progress.GeneratedAt.Format(time.RFC3339)))
+	file.WriteString(fmt.Sprintf("![total](%s/total.svg)\n\n", badgePath))
+	file.WriteString(fmt.Sprintf("`%s` **%.1f%%** (%d / %d apis in production)\n",
+		progress.Total.bar(),
+		progress.Total.Rate,
+		progress.Total.Production,
+		progress.Total.Apis,
+	))
+
+	// PROCESS: 書き込み(サービス別)
+	file.WriteString("\n## Services\n\n")
+	file.WriteString("  | Service | Progress | Rate | Production | Mock | Active | Deprecated | Badge |\n")
+	file.WriteString("  |---|---|--:|--:|--:|--:|--:|---|\n")
+	for _, service := range progress.Services {
+		file.WriteString(fmt.Sprintf("  | %s(%s) | `%s` | %.1f%% | %d | %d | %d | %d | ![%s](%s/%s.svg) |\n",
+			service.Title,
+			service.Name,
+			service.Count.bar(),
+			service.Count.Rate,
+			service.Count.Production,
+			service.Count.Mock,
+			service.Count.Active,
+			service.Count.Deprecated,
+			service.Name,
+			badgePath,
+			service.Name,
+		))
+	}
+	file.WriteString(fmt.Sprintf("  | **total** | `%s` | %.1f%% | %d | %d | %d | %d | |\n",
+		progress.Total.bar(),
+		progress.Total.Rate,
+		progress.Total.Production,
+		progress.Total.Mock,
+		progress.Total.Active,
+		progress.Total.Deprecated,
+	))
+
+	// PROCESS: 書き込み(推移)
+	if len(history) < 2 {
+		return nil
+	}
+	file.WriteString("\n## Trend\n\n")
+	file.WriteString("  | Generated at | Progress | Rate | Production | Apis | Diff |\n")
+	file.WriteString("  |---|---|--:|--:|--:|--:|\n")
+	start := max(len(history)-PROGRESS_TREND_RECENTS, 0)
+	for i := start; i < len(history); i++ {
+		item := history[i]
+		diff := "-"
+		if i > 0 {
+			diff = fmt.Sprintf("%+d", item.Total.Production-history[i-1].Total.Production)
+		}
+		file.WriteString(fmt.Sprintf("  | %s | `%s` | %.1f%% | %d | %d | %s |\n",
+			item.GeneratedAt.Format(time.RFC3339),
+			item.Total.bar(),
+			item.Total.Rate,
+			item.Total.Production,
+			item.Total.Apis,
+			diff,
+		))
+	}
+	return nil
+}
+
+// FUNCTION: SVGバッジの書き込み(サービス別+合計)
+func (progress *Progress) Badges(dir string) error {
+	if err := writeBadge(filepath.Join(dir, "total.svg"), "api progress", progress.Total); err != nil {
+		return err
+	}
+	for _, service := range progress.Services {
+		if err := writeBadge(filepath.Join(dir, service.Name+".svg"), service.Name, service.Count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FUNCTION: SVGバッジ(shields.io風)
+func writeBadge(path string, label string, count ProgressCount) error {
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	// INFO: 幅は文字数からの概算(1文字7px+余白10px)
+	value := fmt.Sprintf("%.1f%% (%d/%d)", count.Rate, count.Production, count.Apis)
+	labelWidth := len([]rune(label))*7 + 10
+	valueWidth := len([]rune(value))*7 + 10
+	width := labelWidth + valueWidth
+	file.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">
+  <title>%s: %s</title>
+  <rect width="%d" height="20" rx="3" fill="#555"/>
+  <rect x="%d" width="%d" height="20" rx="3" fill="%s"/>
+  <rect x="%d" width="4" height="20" fill="%s"/>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%d" y="14">%s</text>
+    <text x="%d" y="14">%s</text>
+  </g>
+</svg>
+`,
+		width, html.EscapeString(label), value,
+		html.EscapeString(label), value,
+		width,
+		labelWidth, valueWidth, badgeColor(count.Rate),
+		labelWidth, badgeColor(count.Rate),
+		labelWidth/2, html.EscapeString(label),
+		labelWidth+valueWidth/2, value,
+	))
+	return nil
+}
+
+// FUNCTION: 実装率に応じたバッジの色
+func badgeColor(rate float64) string {
+	switch {
+	case rate >= 100:
+		return "#4c1"
+	case rate >= 75:
+		return "#97ca00"
+	case rate >= 50:
+		return "#dfb317"
+	case rate >= 25:
+		return "#fe7d37"
+	default:
+		return "#e05d44"
+	}
+}
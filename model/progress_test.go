@@ -0,0 +1,75 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// FUNCTION: Service別・合計の実装進捗を集計し、バッジ・履歴を出力すること
+func TestProgress(t *testing.T) {
+	spec := strings.Replace(documentSpec, "      operationId: orders.delete\n", "      operationId: orders.delete\n      deprecated: true\n", 1)
+	apiList := ApiList{Services: []Service{
+		newTestService(t, "sample", taggedSpec, "items.get", "items.post"),
+		newTestService(t, "orders", spec, "orders.put"),
+		newTestService(t, "receivings", samePathSpec),
+	}}
+	at := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	progress, err := apiList.Progress(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ProgressCount{Apis: 6, Production: 3, Mock: 3, Active: 5, Deprecated: 1, Rate: 50}); progress.Total != want {
+		t.Errorf("total = %+v, want %+v", progress.Total, want)
+	}
+	rates := []float64{}
+	for _, service := range progress.Services {
+		rates = append(rates, service.Count.Rate)
+	}
+	if len(rates) != 3 || rates[0] != 100 || rates[1] != 50 || rates[2] != 0 {
+		t.Errorf("rates = %v", rates)
+	}
+	if bar := progress.Total.bar(); bar != strings.Repeat("█", 10)+strings.Repeat("░", 10) {
+		t.Errorf("bar = %s", bar)
+	}
+
+	// PROCESS: バッジ
+	dir := t.TempDir()
+	if err := progress.Badges(dir); err != nil {
+		t.Fatal(err)
+	}
+	badge, err := os.ReadFile(filepath.Join(dir, "total.svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(badge), "50.0% (3/6)") || !strings.Contains(string(badge), badgeColor(50)) {
+		t.Errorf("badge = %s", badge)
+	}
+	for _, name := range []string{"sample.svg", "orders.svg", "receivings.svg"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// PROCESS: 履歴(存在しない場合は空)
+	path := filepath.Join(dir, "progress-history.json")
+	history, err := LoadProgressHistory(path)
+	if err != nil || len(history) != 0 {
+		t.Fatalf("history = %v, %v", history, err)
+	}
+	if err := SaveProgressHistory(path, append(history, *progress)); err != nil {
+		t.Fatal(err)
+	}
+	history, err = LoadProgressHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || !history[0].GeneratedAt.Equal(at) || history[0].Total != progress.Total {
+		t.Errorf("history = %+v", history)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+// FUNCTION: ユーザー定義テンプレートの出力
+// INFO: テンプレートにはApiListDocument(JSON出力と同じ公開モデル)を渡す
+func (apiList *ApiList) Render(templatePath string, path string) error {
+	source, err := os.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("cannot read file: %w", err)
+	}
+	return apiList.render(filepath.Base(templatePath), string(source), path, false)
+}
+
+// FUNCTION: 組込みテンプレートの出力
+func (apiList *ApiList) renderBuiltin(name string, path string, bom bool) error {
+	source, err := apiList.builtinSource(name)
+	if err != nil {
+		return err
+	}
+	return apiList.render(name, source, path, bom)
+}
+
+// FUNCTION: 組込みテンプレートの取得
+// INFO: templateDirに同名のファイルがある場合はそちらを優先する
+func (apiList *ApiList) builtinSource(name string) (string, error) {
+	source, err := templates.ReadFile("templates/" + name)
+	if err != nil {
+		return "", err
+	}
+	if apiList.TemplateDir != "" {
+		override, err := os.ReadFile(filepath.Join(apiList.TemplateDir, name))
+		if err == nil {
+			source = override
+		} else if !os.IsNotExist(err) {
+			return "", fmt.Errorf("cannot read file: %w", err)
+		}
+	}
+	return string(source), nil
+}
+
+// FUNCTION: テンプレートの出力
+func (apiList *ApiList) render(name string, source string, path string, bom bool) error {
+	// PROCESS: テンプレートのパース
+	tmpl, err := apiList.parseTemplate(name, source)
+	if err != nil {
+		return err
+	}
+
+	// PROCESS: 出力用データの作成
+	document, err := apiList.Document()
+	if err != nil {
+		return err
+	}
+
+	// PROCESS: 書き込み
+	return executeTemplate(tmpl, path, bom, document)
+}
+
+// FUNCTION: テンプレートのパース
+func (apiList *ApiList) parseTemplate(name string, source string) (*template.Template, error) {
+	// INFO: mermaidはApiListに依存するため、呼び出し毎に登録する
+	return template.New(name).Funcs(templateFuncs).Funcs(template.FuncMap{"mermaid": apiList.Mermaid}).Parse(source)
+}
+
+// FUNCTION: テンプレートの書き込み
+func executeTemplate(tmpl *template.Template, path string, bom bool, data any) error {
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	// INFO: Excelで文字化けしないようにBOM付きUTF8とする(tsv)
+	if bom {
+		file.Write([]byte{0xEF, 0xBB, 0xBF})
+	}
+	return tmpl.Execute(file, data)
+}
+
+// テンプレートで利用できる関数
+var templateFuncs = template.FuncMap{
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"join":      func(values []string, sep string) string { return strings.Join(values, sep) },
+	"contains":  func(values []string, value string) bool { return slices.Contains(values, value) },
+	"regexPath": routeRegex,
+	"statusIcon": func(implemented bool) string {
+		if implemented {
+			return PROD
+		}
+		return MOCK
+	},
+	"bodyName": func(body *BodyDocument) string {
+		if body == nil {
+			return "N/A"
+		}
+		return body.Description
+	},
+	"responseNames": func(responses []ResponseDocument) string {
+		names := []string{}
+		for _, res := range responses {
+			if res.Status == "default" {
+				names = append(names, res.Status)
+			} else {
+				names = append(names, fmt.Sprintf("%s(%s)", res.Status, res.Description))
+			}
+		}
+		return strings.Join(names, ", ")
+	},
+	"tsv":     tsvLine,
+	"json":    jsonIndent,
+	"apiPage": apiPage,
+}
+
+// FUNCTION: JSON(インデント付き、HTMLエスケープしない)
+func jsonIndent(value interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// FUNCTION: API詳細ページの相対パス(一覧からのリンク)
+func apiPage(serviceName string, operationId string) string {
+	return fmt.Sprintf("apis/%s/%s.md", serviceName, operationId)
+}
+
+// FUNCTION: tsvの1行(必要に応じてクォートする、改行は含まない)
+func tsvLine(values ...interface{}) string {
+	fields := []string{}
+	for _, value := range values {
+		fields = append(fields, fmt.Sprint(value))
+	}
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma = '\t'
+	writer.Write(fields)
+	writer.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
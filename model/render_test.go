@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// FUNCTION: 公開モデルと関数を使ったユーザー定義テンプレートを出力すること
+func TestRender(t *testing.T) {
+	apiList := ApiList{Services: []Service{newTestService(t, "sample", taggedSpec, "items.get")}}
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "routes.tmpl")
+	source := `{{range .Services}}{{$service := .Name}}{{range .Apis}}{{tsv (upper .Method) (regexPath $service .Path) .Summary (statusIcon .ApiKey.Implemented)}}
+{{end}}{{end}}`
+	if err := os.WriteFile(templatePath, []byte(source), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "routes.tsv")
+	if err := apiList.Render(templatePath, path); err != nil {
+		t.Fatal(err)
+	}
+	output, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "GET\t/sample/items\t商品一覧取得\t" + PROD + "\nPOST\t/sample/items\t商品登録\t" + MOCK + "\n"
+	if string(output) != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+
+	// PROCESS: 不正なテンプレート
+	if err := os.WriteFile(templatePath, []byte("{{range .Services}"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := apiList.Render(templatePath, path); err == nil {
+		t.Error("broken template must be an error")
+	}
+}
+
+// FUNCTION: templateDirに同名のファイルがある場合は組込みテンプレートより優先すること
+func TestBuiltinSourceOverride(t *testing.T) {
+	apiList := ApiList{TemplateDir: t.TempDir()}
+	builtin, err := apiList.builtinSource("api-list.md.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(apiList.TemplateDir, "api-list.md.tmpl"), []byte("custom"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	override, err := apiList.builtinSource("api-list.md.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if builtin == "custom" || override != "custom" {
+		t.Errorf("override = %q", override)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import "fmt"
+
+const (
+	RESOURCE_API     = "API"
+	RESOURCE_SERVICE = "SERVICE"
+	RESOURCE_TAG     = "TAG"
+)
+
+// FUNCTION: 親リソース(Service/Tag)
+func (service *Service) parentResources() ([]AclResource, error) {
+	resources := []AclResource{{
+		ResourceId:   service.ResourceId,
+		ResourceType: RESOURCE_SERVICE,
+		ResourceName: fmt.Sprintf("%s(%s)", service.openapi.description, service.ServiceName),
+	}}
+	for _, tag := range service.openapi.tags {
+		tagKey, err := service.getTagKey(tag.name)
+		if err != nil {
+			return nil, err
+		}
+		name := tag.name
+		if tag.description != "" {
+			name = fmt.Sprintf("%s(%s)", tag.description, tag.name)
+		}
+		resources = append(resources, AclResource{
+			ResourceId:   tagKey.ResourceId,
+			ResourceType: RESOURCE_TAG,
+			ResourceName: name,
+		})
+	}
+	return resources, nil
+}
+
+// FUNCTION: リソースの親子関係
+// INFO: Tag => Service、API => Service/Tag(複数可)
+func (service *Service) resourceRelations() ([]AclRelation, error) {
+	relations := []AclRelation{}
+	for _, tag := range service.openapi.tags {
+		tagKey, err := service.getTagKey(tag.name)
+		if err != nil {
+			return nil, err
+		}
+		relations = append(relations, AclRelation{ResourceId: tagKey.ResourceId, ParentResourceId: service.ResourceId})
+	}
+	for _, api := range service.openapi.apis {
+		apiKey, err := service.getApikey(api.operationId)
+		if err != nil {
+			return nil, err
+		}
+		relations = append(relations, AclRelation{ResourceId: apiKey.ResourceId, ParentResourceId: service.ResourceId})
+		for _, name := range api.tags {
+			tagKey, err := service.getTagKey(name)
+			if err != nil {
+				return nil, err
+			}
+			relations = append(relations, AclRelation{ResourceId: apiKey.ResourceId, ParentResourceId: tagKey.ResourceId})
+		}
+	}
+	return relations, nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+// FUNCTION: Service/Tagを親リソースとし、APIをService・Tagの子とすること
+func TestResourceHierarchy(t *testing.T) {
+	service := newTestService(t, "sample", taggedSpec)
+
+	resources, err := service.parentResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(resources, []AclResource{
+		{ResourceId: "SVC-sample", ResourceType: RESOURCE_SERVICE, ResourceName: "商品API(sample)"},
+		{ResourceId: "TAG-item", ResourceType: RESOURCE_TAG, ResourceName: "商品(item)"},
+	}) {
+		t.Errorf("resources = %v", resources)
+	}
+
+	relations, err := service.resourceRelations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AclRelation{
+		{ResourceId: "TAG-item", ParentResourceId: "SVC-sample"},
+		{ResourceId: "API-items.get", ParentResourceId: "SVC-sample"},
+		{ResourceId: "API-items.get", ParentResourceId: "TAG-item"},
+		{ResourceId: "API-items.post", ParentResourceId: "SVC-sample"},
+		{ResourceId: "API-items.post", ParentResourceId: "TAG-item"},
+	}
+	if !slices.Equal(relations, want) {
+		t.Errorf("relations = %v, want %v", relations, want)
+	}
+
+	// PROCESS: 設定ファイルに無いタグはエラー
+	service.Tags = nil
+	if _, err := service.parentResources(); err == nil {
+		t.Error("unknown tag must be an error")
+	}
+}
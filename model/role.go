@@ -0,0 +1,75 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"log"
+	"path"
+	"slices"
+)
+
+// TITLE: Role構造体(api-setup.yamlの`roles`)
+// INFO: apisにはoperationIdのパターン(`orders.*`など、path.Matchの形式)を指定する
+type Role struct {
+	Name string   `yaml:"name"`
+	Apis []string `yaml:"apis"`
+}
+
+// FUNCTION: APIに権限を付与するロール(`x-roles`と`roles`の和集合)
+func (apiList *ApiList) apiRoles(api Api) []string {
+	roles := slices.Clone(api.roles)
+	for _, role := range apiList.Roles {
+		if role.matches(api.operationId) {
+			roles = append(roles, role.Name)
+		}
+	}
+	return uniqueSorted(roles)
+}
+
+// FUNCTION: operationIdがパターンに一致するか
+func (role Role) matches(operationId string) bool {
+	for _, pattern := range role.Apis {
+		if ok, _ := path.Match(pattern, operationId); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FUNCTION: 全ロール名(昇順)
+func (apiList *ApiList) roleNames() []string {
+	names := []string{}
+	for _, role := range apiList.Roles {
+		names = append(names, role.Name)
+	}
+	for _, service := range apiList.Services {
+		for _, api := range service.openapi.apis {
+			names = append(names, api.roles...)
+		}
+	}
+	return uniqueSorted(names)
+}
+
+// FUNCTION: どのAPIにも一致しないパターンの警告
+func (apiList *ApiList) checkRoles() {
+	for _, role := range apiList.Roles {
+		for _, pattern := range role.Apis {
+			if _, err := path.Match(pattern, ""); err != nil {
+				log.Printf("WARNING: role '%s' has an invalid pattern '%s'.", role.Name, pattern)
+				continue
+			}
+			found := false
+			for _, service := range apiList.Services {
+				for _, api := range service.openapi.apis {
+					if ok, _ := path.Match(pattern, api.operationId); ok {
+						found = true
+					}
+				}
+			}
+			if !found {
+				log.Printf("WARNING: role '%s' pattern '%s' matches no api.", role.Name, pattern)
+			}
+		}
+	}
+}
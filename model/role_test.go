@@ -0,0 +1,64 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+// パス・オペレーションにロールを持つスキーマ
+const roleSpec = `
+openapi: 3.0.3
+info:
+  title: Orders
+  version: 1.0.0
+paths:
+  /orders:
+    x-roles: [clerk]
+    get:
+      operationId: orders.get
+      x-roles: [auditor, clerk]
+      responses:
+        '200':
+          description: OK
+    post:
+      operationId: orders.post
+      responses:
+        '200':
+          description: OK
+  /reports:
+    get:
+      operationId: reports.get
+      responses:
+        '200':
+          description: OK
+`
+
+// FUNCTION: `x-roles`と設定ファイルの`roles`の和集合でロールを付与すること
+func TestApiRoles(t *testing.T) {
+	apiList := ApiList{
+		Roles: []Role{
+			{Name: "admin", Apis: []string{"*"}},
+			{Name: "manager", Apis: []string{"orders.*"}},
+		},
+		Services: []Service{newTestService(t, "orders", roleSpec)},
+	}
+	roles := map[string][]string{}
+	for _, api := range apiList.Services[0].openapi.apis {
+		roles[api.operationId] = apiList.apiRoles(api)
+	}
+	for operationId, want := range map[string][]string{
+		"orders.get":  {"admin", "auditor", "clerk", "manager"},
+		"orders.post": {"admin", "clerk", "manager"},
+		"reports.get": {"admin"},
+	} {
+		if !slices.Equal(roles[operationId], want) {
+			t.Errorf("%s: roles = %v, want %v", operationId, roles[operationId], want)
+		}
+	}
+	if names := apiList.roleNames(); !slices.Equal(names, []string{"admin", "auditor", "clerk", "manager"}) {
+		t.Errorf("role names = %v", names)
+	}
+}
@@ -0,0 +1,314 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Pathパラメータを置き換える正規表現
+const PARAM_REGEX = `[A-Za-z0-9_-]+`
+
+// TITLE: Route構造体
+type Route struct {
+	Id          string
+	Name        string
+	ServiceName string
+	ApiKey      ApiKey
+	Method      string
+	Path        string
+	Regex       string
+	Priority    int
+	Headers     map[string]string
+	Server      Server
+	IsMock      bool
+	api         Api
+	pattern     *regexp.Regexp
+}
+
+// TITLE: RouteConflict構造体
+type RouteConflict struct {
+	Route1 Route
+	Route2 Route
+}
+
+// FUNCTION: Routeの作成
+func (apiList *ApiList) Routes() ([]Route, error) {
+	routes := []Route{}
+	for _, service := range apiList.Services {
+		ls, err := service.routes(apiList.MockHeader)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, ls...)
+	}
+	return routes, nil
+}
+
+// FUNCTION: ServiceのRouteの作成
+// INFO: mockHeaderが指定された場合、ヘッダー付きでMockに転送するRouteを併せて作成する
+// INFO: regex_priorityは偶数をデフォルトのRoute、奇数をヘッダー付きのRouteに割り当て、同じパスのデフォルトのRouteより優先させる
+func (service *Service) routes(mockHeader *MockHeader) ([]Route, error) {
+	routes := []Route{}
+	for _, api := range service.openapi.apis {
+		// ApiKeyの取得
+		apiKey, err := service.getApikey(api.operationId)
+		if err != nil {
+			return nil, err
+		}
+		// Production/Mock
+		server := service.MockServer
+		if apiKey.Implemented {
+			server = service.ProdServer
+		}
+
+		regex := routeRegex(service.ServiceName, api.path)
+		pattern, err := regexp.Compile("^" + regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route regex '%s'(%s): %w", regex, api.operationId, err)
+		}
+		route := Route{
+			Id:          apiKey.KongId,
+			Name:        fmt.Sprintf("%s(%s)", api.summary, api.operationId),
+			ServiceName: service.ServiceName,
+			ApiKey:      *apiKey,
+			Method:      strings.ToUpper(api.method),
+			Path:        api.path,
+			Regex:       regex,
+			Priority:    routePriority(service.ServiceName, api.path) * 2,
+			Server:      server,
+			IsMock:      !apiKey.Implemented,
+			api:         api,
+			pattern:     pattern,
+		}
+		routes = append(routes, route)
+
+		// PROCESS: ヘッダー切替用のMock Route
+		if mockHeader != nil && mockHeader.Name != "" {
+			route.Id = deriveId(apiKey.KongId, "mock")
+			route.Name = fmt.Sprintf("%s(MOCK)", route.Name)
+			route.Priority++
+			route.Headers = map[string]string{mockHeader.Name: mockHeader.value()}
+			route.Server = service.MockServer
+			route.IsMock = true
+			routes = append(routes, route)
+		}
+	}
+	return routes, nil
+}
+
+// FUNCTION: 重複するRouteの検出
+// INFO: 絞り込み対象外のRouteとも重複しうるため、絞り込み前の全Routeで検出する
+func (apiList *ApiList) RouteConflicts() ([]RouteConflict, error) {
+	routes, err := apiList.all().Routes()
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []RouteConflict{}
+	for i := 0; i < len(routes); i++ {
+		for j := i + 1; j < len(routes); j++ {
+			// INFO: ヘッダー付きのRouteはデフォルトのRouteと同じ組み合わせで重複するため対象外
+			if len(routes[i].Headers) > 0 || len(routes[j].Headers) > 0 {
+				continue
+			}
+			if routes[i].overlaps(routes[j]) {
+				conflicts = append(conflicts, RouteConflict{Route1: routes[i], Route2: routes[j]})
+			}
+		}
+	}
+
+	// PROCESS: 出力順を安定させる
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].String() < conflicts[j].String()
+	})
+	return conflicts, nil
+}
+
+// FUNCTION: リクエストにマッチするRouteの検索
+// INFO: 1つ目の戻り値はメソッド・パスともにマッチしたRoute(優先度順、先頭が採用されるRoute)、2つ目はパスのみマッチしたRoute
+func (apiList *ApiList) MatchRoutes(method string, url string, headers map[string]string) ([]Route, []Route, error) {
+	routes, err := apiList.Routes()
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Priority > routes[j].Priority
+	})
+
+	// PROCESS: クエリ文字列は評価対象外
+	path, _, _ := strings.Cut(url, "?")
+
+	matched := []Route{}
+	others := []Route{}
+	for _, route := range routes {
+		match, err := route.MatchPath(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !match || !route.MatchHeaders(headers) {
+			continue
+		}
+		if route.Method == strings.ToUpper(method) {
+			matched = append(matched, route)
+		} else {
+			others = append(others, route)
+		}
+	}
+	return matched, others, nil
+}
+
+// FUNCTION: パスがRouteの正規表現にマッチするか(Kongと同様に先頭一致)
+// INFO: 正規表現はRoute作成時にコンパイルしたものを使用する
+func (route Route) MatchPath(path string) (bool, error) {
+	pattern := route.pattern
+	if pattern == nil {
+		compiled, err := regexp.Compile("^" + route.Regex)
+		if err != nil {
+			return false, fmt.Errorf("invalid route regex '%s': %w", route.Regex, err)
+		}
+		pattern = compiled
+	}
+	return pattern.MatchString(path), nil
+}
+
+// FUNCTION: リクエストヘッダーがRouteの条件を満たすか(名前・値ともに大文字小文字を区別しない)
+func (route Route) MatchHeaders(headers map[string]string) bool {
+	for name, value := range route.Headers {
+		matched := false
+		for key, val := range headers {
+			if strings.EqualFold(key, name) && strings.EqualFold(val, value) {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// FUNCTION: タグ(Mockに転送する場合は`mock`を付与)
+func (route Route) Tags() []string {
+	if route.IsMock {
+		return []string{route.ServiceName, "mock"}
+	}
+	return []string{route.ServiceName}
+}
+
+// FUNCTION: 転送先の表記
+func (route Route) Target() string {
+	kind := "prod"
+	if route.IsMock {
+		kind = "mock"
+	}
+	if len(route.Server.Targets) > 0 {
+		targets := []string{}
+		for _, target := range route.Server.Targets {
+			targets = append(targets, target.Target)
+		}
+		return fmt.Sprintf("%s(%s) %s{%s} [%s]", route.ServiceName, kind, upstreamName(route.ServiceName, route.IsMock), strings.Join(targets, ", "), route.Server.ServiceId)
+	}
+	return fmt.Sprintf("%s(%s) %s:%d [%s]", route.ServiceName, kind, route.Server.Host, route.Server.Port, route.Server.ServiceId)
+}
+
+// FUNCTION: 同一リクエストに両方のRouteがマッチし得るか
+// INFO: Kongの正規表現パスは先頭一致のため、セグメント数が少ない側を前方一致で比較する
+func (route Route) overlaps(other Route) bool {
+	if route.Method != other.Method {
+		return false
+	}
+	segs1 := routeSegments(route.ServiceName, route.Path)
+	segs2 := routeSegments(other.ServiceName, other.Path)
+	if len(segs1) > len(segs2) {
+		segs1, segs2 = segs2, segs1
+	}
+	for i := range segs1 {
+		if !segmentOverlaps(segs1[i], segs2[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// FUNCTION: 優先度で解決できない(曖昧な)重複かどうか
+func (conflict RouteConflict) Ambiguous() bool {
+	return conflict.Route1.Priority == conflict.Route2.Priority
+}
+
+// FUNCTION: 優先されるRoute
+func (conflict RouteConflict) Winner() Route {
+	if conflict.Route2.Priority > conflict.Route1.Priority {
+		return conflict.Route2
+	}
+	return conflict.Route1
+}
+
+// FUNCTION: 文字列表現
+func (conflict RouteConflict) String() string {
+	return fmt.Sprintf("%s %s(%s, priority=%d) <-> %s(%s, priority=%d)",
+		conflict.Route1.Method,
+		conflict.Route1.Regex,
+		conflict.Route1.ApiKey.OperationId,
+		conflict.Route1.Priority,
+		conflict.Route2.Regex,
+		conflict.Route2.ApiKey.OperationId,
+		conflict.Route2.Priority,
+	)
+}
+
+// FUNCTION: Kongに登録するパス正規表現(パラメータ以外の文字列はエスケープする)
+func routeRegex(serviceName string, path string) string {
+	literals := re.Split(fmt.Sprintf("/%s%s", serviceName, path), -1)
+	for i, literal := range literals {
+		literals[i] = regexp.QuoteMeta(literal)
+	}
+	return strings.Join(literals, PARAM_REGEX)
+}
+
+// FUNCTION: regex_priority
+// INFO: セグメント数の多いRouteを優先し、同数の場合は固定文字列のセグメントが多いRouteを優先する
+func routePriority(serviceName string, path string) int {
+	segs := routeSegments(serviceName, path)
+	literals := 0
+	for _, seg := range segs {
+		if !re.MatchString(seg) {
+			literals++
+		}
+	}
+	return len(segs)*100 + literals
+}
+
+// FUNCTION: パスのセグメント分割(サービス名を含む)
+func routeSegments(serviceName string, path string) []string {
+	return strings.Split(strings.Trim(fmt.Sprintf("/%s%s", serviceName, path), "/"), "/")
+}
+
+// FUNCTION: セグメント同士が同じ文字列にマッチし得るか
+func segmentOverlaps(seg1 string, seg2 string) bool {
+	param1 := re.MatchString(seg1)
+	param2 := re.MatchString(seg2)
+	switch {
+	case param1 && param2:
+		return true
+	case param1:
+		return segmentRegex(seg1).MatchString(seg2)
+	case param2:
+		return segmentRegex(seg2).MatchString(seg1)
+	default:
+		return seg1 == seg2
+	}
+}
+
+// FUNCTION: パラメータを含むセグメントの正規表現
+func segmentRegex(seg string) *regexp.Regexp {
+	literals := re.Split(seg, -1)
+	for i, literal := range literals {
+		literals[i] = regexp.QuoteMeta(literal)
+	}
+	return regexp.MustCompile("^" + strings.Join(literals, PARAM_REGEX) + "$")
+}
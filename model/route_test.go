@@ -0,0 +1,214 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// FUNCTION: 固定文字列をエスケープした正規表現でマッチすること
+func TestRouteRegexLiteral(t *testing.T) {
+	regex := routeRegex("sample", "/v1.0/items/{itemId}")
+	if regex != `/sample/v1\.0/items/`+PARAM_REGEX {
+		t.Errorf("routeRegex = %s", regex)
+	}
+
+	route := Route{Regex: regex}
+	for path, want := range map[string]bool{
+		"/sample/v1.0/items/1": true,
+		"/sample/v1x0/items/1": false,
+	} {
+		got, err := route.MatchPath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("MatchPath(%s) = %t, want %t", path, got, want)
+		}
+	}
+
+	// PROCESS: 取り込み時にKongのパスとopenapiのパスを対応付けられること
+	if got, want := pathPattern("~"+regex, true), pathPattern("/sample/v1.0/items/{itemId}", false); got != want {
+		t.Errorf("pathPattern = %s, want %s", got, want)
+	}
+
+	// PROCESS: 不正な正規表現はpanicせずエラー
+	if _, err := (Route{Regex: "/sample/("}).MatchPath("/sample/"); err == nil {
+		t.Error("invalid regex must be an error")
+	}
+}
+
+// FUNCTION: テスト用のService(スキーマはテストごとに記述する)
+// INFO: implementedに含まれるオペレーションのみ実装済みとする
+func newTestService(t *testing.T, serviceName string, spec string, implemented ...string) Service {
+	t.Helper()
+	openapi, err := parseOpenapi([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := Service{
+		ServiceName: serviceName,
+		ResourceId:  "SVC-" + serviceName,
+		openapi:     *openapi,
+		ProdServer:  Server{Host: serviceName, Port: 8080, ServiceId: uuid.NewString()},
+		MockServer:  Server{Host: serviceName + "-mock", Port: 8081, ServiceId: uuid.NewString()},
+	}
+	for _, tag := range openapi.tags {
+		service.Tags = append(service.Tags, TagKey{Name: tag.name, ResourceId: "TAG-" + tag.name})
+	}
+	for _, api := range openapi.apis {
+		service.Apis = append(service.Apis, ApiKey{
+			OperationId: api.operationId,
+			KongId:      uuid.NewString(),
+			ResourceId:  "API-" + api.operationId,
+			Implemented: slices.Contains(implemented, api.operationId),
+		})
+	}
+	return service
+}
+
+// 重複するパスを持つスキーマ
+const conflictSpec = `
+openapi: 3.0.3
+info:
+  title: Conflict
+  version: 1.0.0
+paths:
+  /items/{itemId}:
+    get:
+      operationId: items.get
+      responses:
+        '200':
+          description: OK
+  /items/search:
+    get:
+      operationId: items.search
+      responses:
+        '200':
+          description: OK
+  /items/{itemCode}:
+    get:
+      operationId: items.getByCode
+      responses:
+        '200':
+          description: OK
+  /items/{itemId}/stocks:
+    get:
+      operationId: stocks.get
+      responses:
+        '200':
+          description: OK
+`
+
+// FUNCTION: 固定文字列の多いRouteを優先し、優先度で解決できない重複を曖昧と判定すること
+func TestRouteConflicts(t *testing.T) {
+	apiList := ApiList{Services: []Service{newTestService(t, "sample", conflictSpec)}}
+	conflicts, err := apiList.RouteConflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := map[string]string{}
+	for _, conflict := range conflicts {
+		pair := []string{conflict.Route1.ApiKey.OperationId, conflict.Route2.ApiKey.OperationId}
+		slices.Sort(pair)
+		key := strings.Join(pair, "/")
+		if conflict.Ambiguous() {
+			results[key] = "ambiguous"
+		} else {
+			results[key] = conflict.Winner().ApiKey.OperationId
+		}
+	}
+	want := map[string]string{
+		"items.get/items.search":       "items.search",
+		"items.get/items.getByCode":    "ambiguous",
+		"items.getByCode/items.search": "items.search",
+		"items.get/stocks.get":         "stocks.get",
+		"items.search/stocks.get":      "stocks.get",
+		"items.getByCode/stocks.get":   "stocks.get",
+	}
+	if !maps.Equal(results, want) {
+		t.Errorf("conflicts = %v, want %v", results, want)
+	}
+}
+
+// FUNCTION: メソッド・パスにマッチするRouteを優先度順に返すこと
+func TestMatchRoutes(t *testing.T) {
+	apiList := ApiList{Services: []Service{newTestService(t, "sample", conflictSpec, "items.search")}}
+	for _, tt := range []struct {
+		method  string
+		url     string
+		matched []string
+		others  int
+	}{
+		{"GET", "/sample/items/search?q=1", []string{"items.search", "items.get", "items.getByCode"}, 0},
+		{"GET", "/sample/items/1/stocks", []string{"stocks.get", "items.get", "items.getByCode"}, 0},
+		{"POST", "/sample/items/1", nil, 2},
+		{"GET", "/other/items/1", nil, 0},
+	} {
+		matched, others, err := apiList.MatchRoutes(tt.method, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		operations := []string{}
+		for _, route := range matched {
+			operations = append(operations, route.ApiKey.OperationId)
+		}
+		// INFO: 同じ優先度のRouteの順序は保証しないため先頭のみ順序を比較する
+		if len(operations) != len(tt.matched) || (len(operations) > 0 && operations[0] != tt.matched[0]) {
+			t.Errorf("%s %s: matched = %v, want %v", tt.method, tt.url, operations, tt.matched)
+		}
+		if len(others) != tt.others {
+			t.Errorf("%s %s: others = %d, want %d", tt.method, tt.url, len(others), tt.others)
+		}
+	}
+
+	// PROCESS: 転送先
+	matched, _, err := apiList.MatchRoutes("GET", "/sample/items/search", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target := matched[0].Target(); !strings.HasPrefix(target, "sample(prod) sample:8080") {
+		t.Errorf("target = %s", target)
+	}
+}
+
+// FUNCTION: ヘッダー付きのRouteを同じパスのデフォルトのRouteより優先してMockに転送すること
+func TestMockHeaderRoutes(t *testing.T) {
+	apiList := ApiList{
+		MockHeader: &MockHeader{Name: "X-Mock"},
+		Services:   []Service{newTestService(t, "sample", conflictSpec, "items.search")},
+	}
+	for _, tt := range []struct {
+		headers map[string]string
+		mock    bool
+	}{
+		{nil, false},
+		{map[string]string{"x-mock": "TRUE"}, true},
+		{map[string]string{"X-Mock": "false"}, false},
+	} {
+		matched, _, err := apiList.MatchRoutes("GET", "/sample/items/search", tt.headers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		winner := matched[0]
+		if winner.ApiKey.OperationId != "items.search" || winner.IsMock != tt.mock {
+			t.Errorf("headers %v: matched %s(mock=%t), want items.search(mock=%t)", tt.headers, winner.ApiKey.OperationId, winner.IsMock, tt.mock)
+		}
+	}
+
+	// PROCESS: ヘッダー付きのRouteは重複として報告しない
+	conflicts, err := apiList.RouteConflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 6 {
+		t.Errorf("conflicts = %d, want 6", len(conflicts))
+	}
+}
@@ -0,0 +1,337 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/teru-0529/api-forge/store"
+)
+
+// TITLE: SpecChange構造体(openapiのバージョン間の変更)
+type SpecChange struct {
+	Service     string
+	OperationId string
+	Method      string
+	Path        string
+	Breaking    bool
+	Message     string
+}
+
+// FUNCTION: openapiの比較(baseがnilの場合は新規のファイルとして扱う)
+func DiffSpecs(service string, base []byte, head []byte) ([]SpecChange, error) {
+	baseApi := &Openapi{}
+	if base != nil {
+		parsed, err := parseOpenapi(base)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse base spec of '%s': %w", service, err)
+		}
+		baseApi = parsed
+	}
+	headApi, err := parseOpenapi(head)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse spec of '%s': %w", service, err)
+	}
+	// INFO: サービス名が未指定の場合は仕様のタイトルを用いる
+	if service == "" {
+		service = headApi.title
+	}
+	return diffOpenapi(service, baseApi, headApi), nil
+}
+
+// FUNCTION: 変更の検出(operationIdで対応付ける)
+func diffOpenapi(service string, base *Openapi, head *Openapi) []SpecChange {
+	changes := []SpecChange{}
+	headApis := map[string]Api{}
+	for _, api := range head.apis {
+		headApis[api.key()] = api
+	}
+	baseKeys := map[string]bool{}
+
+	for _, was := range base.apis {
+		baseKeys[was.key()] = true
+		now, ok := headApis[was.key()]
+		if !ok {
+			changes = append(changes, newSpecChange(service, was, true, "operation removed"))
+			continue
+		}
+		diff := specDiff{}
+		diff.compareApi(was, now)
+		for _, item := range diff {
+			changes = append(changes, newSpecChange(service, now, item.breaking, item.message))
+		}
+	}
+	for _, api := range head.apis {
+		if !baseKeys[api.key()] {
+			changes = append(changes, newSpecChange(service, api, false, "operation added"))
+		}
+	}
+	return changes
+}
+
+// FUNCTION: 対応付けのキー(operationIdが無い場合はメソッド+パス)
+func (api Api) key() string {
+	if api.operationId != "" {
+		return api.operationId
+	}
+	return strings.ToUpper(api.method) + " " + api.path
+}
+
+// FUNCTION: SpecChangeの作成
+func newSpecChange(service string, api Api, breaking bool, message string) SpecChange {
+	return SpecChange{
+		Service:     service,
+		OperationId: api.operationId,
+		Method:      strings.ToUpper(api.method),
+		Path:        api.path,
+		Breaking:    breaking,
+		Message:     message,
+	}
+}
+
+// INFO: 1つのoperation内の変更
+type specDiff []specDiffItem
+
+type specDiffItem struct {
+	breaking bool
+	message  string
+}
+
+// FUNCTION: 変更の追加
+func (diff *specDiff) add(breaking bool, format string, args ...any) {
+	*diff = append(*diff, specDiffItem{breaking: breaking, message: fmt.Sprintf(format, args...)})
+}
+
+// FUNCTION: operationの比較
+func (diff *specDiff) compareApi(was Api, now Api) {
+	// PROCESS: メソッド/パス
+	if was.method != now.method || was.path != now.path {
+		diff.add(true, "endpoint changed: `%s %s` => `%s %s`", strings.ToUpper(was.method), was.path, strings.ToUpper(now.method), now.path)
+	}
+	if !was.deprecated && now.deprecated {
+		diff.add(false, "operation deprecated")
+	}
+
+	// PROCESS: パラメータ
+	for _, param := range now.request.parameters {
+		i := slices.IndexFunc(was.request.parameters, func(p Parameter) bool { return p.in == param.in && p.name == param.name })
+		if i < 0 {
+			if param.required {
+				diff.add(true, "new required parameter `%s` (%s)", param.name, param.in)
+			} else {
+				diff.add(false, "parameter `%s` (%s) added", param.name, param.in)
+			}
+			continue
+		}
+		before := was.request.parameters[i]
+		label := fmt.Sprintf("parameter `%s` (%s)", param.name, param.in)
+		diff.compareRequired(label, before.required, param.required, true)
+		diff.compareType(label, before.kind, param.kind)
+		diff.compareEnum(label, before.enum, param.enum)
+	}
+	for _, param := range was.request.parameters {
+		if !slices.ContainsFunc(now.request.parameters, func(p Parameter) bool { return p.in == param.in && p.name == param.name }) {
+			diff.add(false, "parameter `%s` (%s) removed", param.name, param.in)
+		}
+	}
+
+	// PROCESS: リクエストボディ
+	switch {
+	case !was.request.hasBody && now.request.hasBody:
+		diff.add(now.request.required, "request body added")
+	case was.request.hasBody && !now.request.hasBody:
+		diff.add(false, "request body removed")
+	case was.request.hasBody && now.request.hasBody:
+		diff.compareRequired("request body", was.request.required, now.request.required, true)
+		diff.compareFields("request field", was.request.fields, now.request.fields, true)
+	}
+
+	// PROCESS: レスポンス
+	for _, res := range was.responses {
+		i := slices.IndexFunc(now.responses, func(r Response) bool { return r.status == res.status })
+		if i < 0 {
+			diff.add(true, "response `%s` removed", res.status)
+			continue
+		}
+		after := now.responses[i]
+		diff.compareFields(fmt.Sprintf("response `%s` field", res.status), res.fields, after.fields, false)
+		for _, header := range res.headers {
+			if !slices.ContainsFunc(after.headers, func(h Header) bool { return strings.EqualFold(h.name, header.name) }) {
+				diff.add(true, "response `%s` header `%s` removed", res.status, header.name)
+			}
+		}
+		for _, header := range after.headers {
+			if !slices.ContainsFunc(res.headers, func(h Header) bool { return strings.EqualFold(h.name, header.name) }) {
+				diff.add(false, "response `%s` header `%s` added", res.status, header.name)
+			}
+		}
+	}
+	for _, res := range now.responses {
+		if !slices.ContainsFunc(was.responses, func(r Response) bool { return r.status == res.status }) {
+			diff.add(false, "response `%s` added", res.status)
+		}
+	}
+}
+
+// FUNCTION: 必須の比較
+// INFO: リクエストは必須になると互換性が無くなり、レスポンスは任意になると互換性が無くなる
+func (diff *specDiff) compareRequired(label string, was bool, now bool, request bool) {
+	if was == now {
+		return
+	}
+	if now {
+		diff.add(request, "%s became required", label)
+	} else {
+		diff.add(!request, "%s became optional", label)
+	}
+}
+
+// FUNCTION: 型の比較
+func (diff *specDiff) compareType(label string, was string, now string) {
+	if was != now {
+		diff.add(true, "%s type changed: `%s` => `%s`", label, was, now)
+	}
+}
+
+// FUNCTION: 列挙値の比較(値が減った場合、列挙値が新たに指定された場合は狭まったとみなす)
+func (diff *specDiff) compareEnum(label string, was []string, now []string) {
+	if len(now) == 0 {
+		if len(was) > 0 {
+			diff.add(false, "%s enum removed", label)
+		}
+		return
+	}
+	if len(was) == 0 {
+		diff.add(true, "%s enum narrowed: `%s`", label, strings.Join(now, ", "))
+		return
+	}
+	removed, added := []string{}, []string{}
+	for _, value := range was {
+		if !slices.Contains(now, value) {
+			removed = append(removed, value)
+		}
+	}
+	for _, value := range now {
+		if !slices.Contains(was, value) {
+			added = append(added, value)
+		}
+	}
+	if len(removed) > 0 {
+		diff.add(true, "%s enum narrowed: removed `%s`", label, strings.Join(removed, ", "))
+	}
+	if len(added) > 0 {
+		diff.add(false, "%s enum widened: added `%s`", label, strings.Join(added, ", "))
+	}
+}
+
+// FUNCTION: 項目の比較(子項目は`親.子`の名称で比較する)
+func (diff *specDiff) compareFields(label string, was []Field, now []Field, request bool) {
+	was, now = flattenFields(was, ""), flattenFields(now, "")
+	// INFO: 親項目ごと追加/削除された子項目は親項目の変更として扱う
+	for _, field := range now {
+		i := slices.IndexFunc(was, func(f Field) bool { return f.name == field.name })
+		if i < 0 && !hasParentField(was, field.name) {
+			continue
+		}
+		if i < 0 {
+			if field.required {
+				diff.add(request, "%s `%s` added (required)", label, field.name)
+			} else {
+				diff.add(false, "%s `%s` added", label, field.name)
+			}
+			continue
+		}
+		name := fmt.Sprintf("%s `%s`", label, field.name)
+		diff.compareRequired(name, was[i].required, field.required, request)
+		diff.compareType(name, was[i].kind, field.kind)
+		diff.compareEnum(name, was[i].enum, field.enum)
+	}
+	for _, field := range was {
+		if !hasParentField(now, field.name) {
+			continue
+		}
+		if !slices.ContainsFunc(now, func(f Field) bool { return f.name == field.name }) {
+			diff.add(!request, "%s `%s` removed", label, field.name)
+		}
+	}
+}
+
+// FUNCTION: 子項目を含めた項目の一覧
+func flattenFields(fields []Field, prefix string) []Field {
+	flattened := []Field{}
+	for _, field := range fields {
+		field.name = prefix + field.name
+		flattened = append(flattened, field)
+		flattened = append(flattened, flattenFields(field.fields, field.name+".")...)
+	}
+	return flattened
+}
+
+// FUNCTION: 親項目が存在するかどうか(最上位の項目は常に存在するとみなす)
+func hasParentField(fields []Field, name string) bool {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return true
+	}
+	return slices.ContainsFunc(fields, func(f Field) bool { return f.name == name[:i] })
+}
+
+// FUNCTION: 破壊的変更の件数
+func BreakingCount(changes []SpecChange) int {
+	count := 0
+	for _, change := range changes {
+		if change.Breaking {
+			count++
+		}
+	}
+	return count
+}
+
+// FUNCTION: 変更履歴(MD)の書き込み
+func SpecChangelogMd(path string, title string, changes []SpecChange) error {
+	// PROCESS: Fileの取得
+	file, cleanup, err := store.NewFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// PROCESS: 書き込み
+	file.WriteString(fmt.Sprintf("# API changelog (%s)\n", title))
+	if len(changes) == 0 {
+		file.WriteString("\nNo changes.\n")
+		return nil
+	}
+	file.WriteString(fmt.Sprintf("\n%d breaking / %d non-breaking changes.\n", BreakingCount(changes), len(changes)-BreakingCount(changes)))
+
+	for _, section := range []struct {
+		title    string
+		breaking bool
+	}{{"⚠️ Breaking changes", true}, {"Non-breaking changes", false}} {
+		items := []SpecChange{}
+		for _, change := range changes {
+			if change.Breaking == section.breaking {
+				items = append(items, change)
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+		file.WriteString(fmt.Sprintf("\n## %s\n\n", section.title))
+		file.WriteString("  | Service | Operation | Endpoint | Change |\n")
+		file.WriteString("  |---|---|---|---|\n")
+		for _, item := range items {
+			file.WriteString(fmt.Sprintf("  | %s | %s | %s %s | %s |\n",
+				item.Service,
+				item.OperationId,
+				item.Method,
+				item.Path,
+				item.Message,
+			))
+		}
+	}
+	return nil
+}
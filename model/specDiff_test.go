@@ -0,0 +1,105 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// FUNCTION: allOf・子項目の型の変更を検出し、readOnlyの項目はリクエストの変更としないこと
+func TestDiffSpecsComposedFields(t *testing.T) {
+	base := composedSpec
+	head := strings.Replace(base, "      type: integer\n      format: int32\n", "      type: string\n", 1)
+	changes, err := DiffSpecs("sample", []byte(base), []byte(head))
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := []string{}
+	for _, change := range changes {
+		messages = append(messages, change.Message)
+	}
+
+	for _, want := range []string{
+		"request field `price` type changed: `integer(int32)` => `string`",
+		"response `200` field `price` type changed: `integer(int32)` => `string`",
+		"response `200` field `costPrice` type changed: `integer(int32)` => `string`",
+	} {
+		if !slices.Contains(messages, want) {
+			t.Errorf("missing change %q in %v", want, messages)
+		}
+	}
+	if slices.ContainsFunc(messages, func(m string) bool { return strings.HasPrefix(m, "request field `costPrice`") }) {
+		t.Errorf("readOnly field reported as request change: %v", messages)
+	}
+}
+
+// FUNCTION: 子項目の変更を`親.子`で検出すること
+func TestCompareNestedFields(t *testing.T) {
+	was := []Field{{name: "customer", kind: "customer", fields: []Field{{name: "name", kind: "string"}}}}
+	now := []Field{{name: "customer", kind: "customer", fields: []Field{{name: "name", kind: "integer"}, {name: "code", kind: "string", required: true}}}}
+
+	diff := specDiff{}
+	diff.compareFields("request field", was, now, true)
+	if len(diff) != 2 || !diff[0].breaking || diff[0].message != "request field `customer.name` type changed: `string` => `integer`" || !diff[1].breaking {
+		t.Errorf("diff = %v", diff)
+	}
+
+	// PROCESS: 親項目ごと追加された子項目は報告しない
+	diff = specDiff{}
+	diff.compareFields("request field", nil, now, true)
+	if len(diff) != 1 {
+		t.Errorf("diff = %v, want only the parent", diff)
+	}
+}
+
+// FUNCTION: サービス名が未指定の場合は仕様のタイトルで報告すること
+func TestDiffSpecsServiceTitle(t *testing.T) {
+	head := strings.Replace(composedSpec, "operationId: ", "operationId: renamed.", 1)
+	changes, err := DiffSpecs("", []byte(composedSpec), []byte(head))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) == 0 {
+		t.Fatal("no changes detected")
+	}
+	for _, change := range changes {
+		if change.Service != "Composed" {
+			t.Errorf("service = %q, want the spec title", change.Service)
+		}
+	}
+}
+
+// FUNCTION: オペレーション・パラメータ・レスポンスの変更を破壊的/非破壊的に分類すること
+func TestDiffSpecsBreaking(t *testing.T) {
+	head := strings.NewReplacer(
+		"          required: true\n          schema:\n            type: string\n",
+		"          required: true\n          schema:\n            type: string\n        - name: version\n          in: header\n          required: true\n          schema:\n            type: integer\n",
+		"          headers:\n            X-Request-Id:\n              schema:\n                type: string\n", "",
+		"    delete:\n      tags: [order]\n      operationId: orders.delete\n", "    delete:\n      tags: [order]\n      operationId: orders.remove\n",
+		"  version: 2.1.0\n", "  version: 3.0.0\n",
+	).Replace(documentSpec)
+	changes, err := DiffSpecs("orders", []byte(documentSpec), []byte(head))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]bool{}
+	for _, change := range changes {
+		results[change.OperationId+": "+change.Message] = change.Breaking
+	}
+	want := map[string]bool{
+		"orders.put: new required parameter `version` (header)":    true,
+		"orders.put: response `200` header `X-Request-Id` removed": true,
+		"orders.delete: operation removed":                         true,
+		"orders.remove: operation added":                           false,
+	}
+	if !maps.Equal(results, want) {
+		t.Errorf("changes = %v, want %v", results, want)
+	}
+	if count := BreakingCount(changes); count != 3 {
+		t.Errorf("breaking = %d, want 3", count)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// TITLE: ApplyResult構造体(SQLファイル適用の結果)
+type ApplyResult struct {
+	Path       string
+	Statements int
+	Counts     []StatementCount
+	Committed  bool
+}
+
+// INFO: 同じ操作・テーブルの文は1件にまとめる
+type StatementCount struct {
+	Operation string
+	Table     string
+	Rows      int64
+}
+
+// 操作とテーブル名の抽出
+var statementTarget = regexp.MustCompile(`(?is)^(INSERT\s+INTO|DELETE\s+FROM|UPDATE)\s+([^\s(]+)`)
+
+// FUNCTION: DSNからデータベースを開く
+// INFO: `postgres://`、`mysql://`、`sqlite://`(または`file:`)で始まるDSNに対応する
+func OpenDatabase(dsn string) (*sql.DB, error) {
+	var driver string
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		driver = "postgres"
+	case strings.HasPrefix(dsn, "mysql://"):
+		driver, dsn = "mysql", strings.TrimPrefix(dsn, "mysql://")
+	case strings.HasPrefix(dsn, "sqlite://"):
+		driver, dsn = "sqlite", strings.TrimPrefix(dsn, "sqlite://")
+	case strings.HasPrefix(dsn, "file:"):
+		driver = "sqlite"
+	default:
+		return nil, fmt.Errorf("unsupported dsn: %s", dsn)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// FUNCTION: SQLファイルの適用(1ファイル1トランザクション)
+// INFO: planの場合は件数を取得した後にロールバックする。エラー発生時は最初のエラーでロールバックする
+func ApplySql(db *sql.DB, path string, plan bool) (*ApplyResult, error) {
+	statements, err := readSqlStatements(path)
+	if err != nil {
+		return nil, err
+	}
+	result := ApplyResult{Path: path, Statements: len(statements), Counts: []StatementCount{}}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for i, statement := range statements {
+		res, err := tx.Exec(statement)
+		if err != nil {
+			return &result, fmt.Errorf("%s: statement %d: %w\n  %s", path, i+1, err, firstLine(statement))
+		}
+		rows, _ := res.RowsAffected()
+		result.count(statement, rows)
+	}
+
+	if plan {
+		return &result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return &result, err
+	}
+	result.Committed = true
+	return &result, nil
+}
+
+// FUNCTION: 件数の集計
+func (result *ApplyResult) count(statement string, rows int64) {
+	operation, table := "OTHER", ""
+	if matches := statementTarget.FindStringSubmatch(statement); matches != nil {
+		operation = strings.ToUpper(strings.Fields(matches[1])[0])
+		table = matches[2]
+	}
+	for i, item := range result.Counts {
+		if item.Operation == operation && item.Table == table {
+			result.Counts[i].Rows += rows
+			return
+		}
+	}
+	result.Counts = append(result.Counts, StatementCount{Operation: operation, Table: table, Rows: rows})
+}
+
+// FUNCTION: SQLファイルの文の読込み(コメント行は除く)
+func readSqlStatements(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file: %w", err)
+	}
+	defer file.Close()
+
+	statements := []string{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+
+	var statement strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if statement.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+
+		// PROCESS: 文末(`;`)までを1文として扱う
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if text, complete := completeStatement(statement.String()); complete {
+			statement.Reset()
+			statements = append(statements, strings.TrimSpace(text))
+		}
+	}
+	if strings.TrimSpace(statement.String()) != "" {
+		statements = append(statements, strings.TrimSpace(statement.String()))
+	}
+	return statements, scanner.Err()
+}
+
+// FUNCTION: 文の先頭行(エラー表示用)
+func firstLine(statement string) string {
+	line, _, _ := strings.Cut(statement, "\n")
+	if len(line) > 120 {
+		return line[:120] + "..."
+	}
+	return line
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// 適用するSQL(文字列中の`;`・コメントを含む)
+const applySql = `-- comment
+INSERT INTO items VALUES ('a', 'x;y');
+INSERT INTO items VALUES ('b', '--');
+
+UPDATE items SET name = 'z'
+  WHERE id = 'a';
+`
+
+// FUNCTION: 件数を集計し、plan・エラーの場合はロールバックすること
+func TestApplySql(t *testing.T) {
+	dir := t.TempDir()
+	db, err := OpenDatabase("sqlite://" + filepath.Join(dir, "apply.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE items (id TEXT PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "apply.sql")
+	if err := os.WriteFile(path, []byte(applySql), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// PROCESS: plan
+	result, err := ApplySql(db, path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []StatementCount{{Operation: "INSERT", Table: "items", Rows: 2}, {Operation: "UPDATE", Table: "items", Rows: 1}}
+	if result.Statements != 3 || result.Committed || !slices.Equal(result.Counts, want) {
+		t.Errorf("plan result = %+v", result)
+	}
+	if count := countItems(t, db); count != 0 {
+		t.Errorf("plan committed %d rows", count)
+	}
+
+	// PROCESS: 適用
+	result, err = ApplySql(db, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Committed || countItems(t, db) != 2 {
+		t.Errorf("apply result = %+v", result)
+	}
+
+	// PROCESS: 2回目は主キー重複でエラーとなり、ロールバックする
+	if _, err := db.Exec("DELETE FROM items WHERE id = 'b'"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplySql(db, path, false); err == nil {
+		t.Error("duplicate key must be an error")
+	}
+	if count := countItems(t, db); count != 1 {
+		t.Errorf("rows = %d after rollback, want 1", count)
+	}
+}
+
+// FUNCTION: 件数
+func countItems(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM items").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
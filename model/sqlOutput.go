@@ -4,7 +4,9 @@ Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
 package model
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"regexp"
 	"strings"
 
@@ -17,7 +19,8 @@ const TRACE_ID = "SYS_SETUP"
 var re = regexp.MustCompile(`\{[^}]*\}`)
 
 // FUNCTION: Kong用SQLの書き込み
-func (apiList *ApiList) Sql4Kong(path string) error {
+// INFO: incrementalの場合は全件削除せず、出力対象の行のみ削除/更新する
+func (apiList *ApiList) Sql4Kong(path string, audit Audit, incremental bool) error {
 	// PROCESS: Fileの取得
 	file, cleanup, err := store.NewFile(path)
 	if err != nil {
@@ -27,71 +30,158 @@ func (apiList *ApiList) Sql4Kong(path string) error {
 
 	// PROCESS: 書き込み
 	file.WriteString("-- # service and route data for kong.\n")
+	file.WriteString(apiList.sqlHeader(audit))
+
+	// PROCESS: workspace単位のService
+	scopes, err := apiList.scopedServices()
+	if err != nil {
+		return err
+	}
 
 	file.WriteString("\n-- ----+----+----+----+----+----+----+----+----+----+----+----+----+----+----+\n\n")
-	file.WriteString("-- ## delete tables\n")
-	file.WriteString("DELETE FROM route;\n")
-	file.WriteString("DELETE FROM service;\n")
+	if incremental {
+		deletes, err := kongIncrementalDeletes(scopes, apiList.MockHeader)
+		if err != nil {
+			return err
+		}
+		file.WriteString("-- ## delete rows(incremental)\n")
+		file.WriteString(deletes)
+	} else {
+		file.WriteString("-- ## delete tables\n")
+		file.WriteString("DELETE FROM plugin;\n")
+		file.WriteString("DELETE FROM route;\n")
+		file.WriteString("DELETE FROM service;\n")
+		file.WriteString("DELETE FROM target;\n")
+		file.WriteString("DELETE FROM upstream;\n")
+	}
 
-	for _, service := range apiList.Services {
+	for _, scope := range scopes {
+		service := scope.Service
 		file.WriteString("\n-- ----+----+----+----+----+----+----+----+----+----+----+----+----+----+----+\n\n")
-		file.WriteString(fmt.Sprintf("-- ## %s(%s)\n", service.ServiceName, service.openapi.description))
+		file.WriteString(fmt.Sprintf("-- ## %s\n", scope.title()))
 
 		file.WriteString("\n-- ### Service\n")
 		// prod service
-		file.WriteString(fmt.Sprintf("INSERT INTO service VALUES (%s);\n", serviceParam(
+		file.WriteString(fmt.Sprintf("INSERT INTO service VALUES (%s)%s;\n", serviceParam(
 			service.ProdServer.ServiceId,
 			service.openapi.description,
-			service.ProdServer.Host,
+			service.ProdServer.kongHost(service.ServiceName, false),
 			service.ProdServer.Port,
 			fmt.Sprintf("'%s'", service.ServiceName),
-			apiList.WorkSpaceId),
+			scope.workspaceId),
+			upsertClause(incremental, "name", "host", "port", "tags", "ws_id"),
 		))
 		// mock service
-		file.WriteString(fmt.Sprintf("INSERT INTO service VALUES (%s);\n", serviceParam(
+		file.WriteString(fmt.Sprintf("INSERT INTO service VALUES (%s)%s;\n", serviceParam(
 			service.MockServer.ServiceId,
 			fmt.Sprintf("%s(MOCK)", service.openapi.description),
-			service.MockServer.Host,
+			service.MockServer.kongHost(service.ServiceName, true),
 			service.MockServer.Port,
 			fmt.Sprintf("'%s', 'mock'", service.ServiceName),
-			apiList.WorkSpaceId),
+			scope.workspaceId),
+			upsertClause(incremental, "name", "host", "port", "tags", "ws_id"),
 		))
 
-		file.WriteString("\n-- ### Route\n")
-		for _, api := range service.openapi.apis {
-			// ApiKeyの取得
-			apiKey, err := service.getApikey(api.operationId)
-			if err != nil {
-				return err
+		// PROCESS: Upstream/Target
+		upstreams := service.upstreams()
+		if len(upstreams) > 0 {
+			file.WriteString("\n-- ### Upstream / Target\n")
+			for _, upstream := range upstreams {
+				file.WriteString(fmt.Sprintf("INSERT INTO upstream VALUES (%s)%s;\n", upstreamParams(
+					upstream,
+					fmt.Sprintf("'%s'", service.ServiceName),
+					scope.workspaceId,
+				), upsertClause(incremental, "name", "healthchecks", "tags", "ws_id")))
+				for _, target := range upstream.Targets {
+					file.WriteString(fmt.Sprintf("INSERT INTO target VALUES (%s);\n", targetParams(
+						upstream,
+						target,
+						fmt.Sprintf("'%s'", service.ServiceName),
+						scope.workspaceId,
+					)))
+				}
 			}
+		}
+
+		file.WriteString("\n-- ### Route\n")
+		routes, err := service.routes(apiList.MockHeader)
+		if err != nil {
+			return err
+		}
+		for _, route := range routes {
 			// Production/Mock
-			serverId := service.MockServer.ServiceId
-			tag := fmt.Sprintf("'%s', 'mock'", service.ServiceName)
+			tag := sqlArray(route.Tags())
 			msg := "-- ★★MOCK★★"
-			if apiKey.Implemented {
-				serverId = service.ProdServer.ServiceId
-				tag = fmt.Sprintf("'%s'", service.ServiceName)
+			if !route.IsMock {
 				msg = ""
+			} else if len(route.Headers) > 0 {
+				msg = "-- ★★MOCK(HEADER)★★"
 			}
 
 			file.WriteString(fmt.Sprintf("INSERT INTO route VALUES (%s); %s\n", routeParams(
-				service.ServiceName,
-				apiKey.KongId,
-				api.summary,
-				api.operationId,
-				serverId,
-				strings.ToUpper(api.method),
-				api.path,
+				route.Id,
+				route.Name,
+				route.Server.ServiceId,
+				route.Method,
+				route.Regex,
+				route.Priority,
 				tag,
-				apiList.WorkSpaceId,
+				route.headersJson(),
+				scope.workspaceId,
 			), msg))
 		}
+
+		// PROCESS: Plugin(認証/Rate-Limit/CORS)
+		plugins, err := service.plugins(routes, apiList.AnonymousConsumer)
+		if err != nil {
+			return err
+		}
+		if len(plugins) > 0 {
+			file.WriteString("\n-- ### Plugin\n")
+			for _, plugin := range plugins {
+				file.WriteString(fmt.Sprintf("INSERT INTO plugin VALUES (%s);\n", pluginParams(
+					plugin,
+					fmt.Sprintf("'%s'", service.ServiceName),
+					scope.workspaceId,
+				)))
+			}
+		}
+	}
+
+	// PROCESS: Routeの重複
+	conflicts, err := apiList.RouteConflicts()
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		file.WriteString("\n-- ----+----+----+----+----+----+----+----+----+----+----+----+----+----+----+\n\n")
+		file.WriteString("-- ## route conflicts\n")
+		for _, conflict := range conflicts {
+			if conflict.Ambiguous() {
+				log.Printf("WARNING: ambiguous route: %s", conflict)
+				file.WriteString(fmt.Sprintf("-- [AMBIGUOUS] %s\n", conflict))
+			} else {
+				file.WriteString(fmt.Sprintf("-- [RESOLVED] %s => %s\n", conflict, conflict.Winner().ApiKey.OperationId))
+			}
+		}
 	}
 	return nil
 }
 
 // FUNCTION: Acl用SQLの書き込み
-func (apiList *ApiList) Sql4Acl(path string) error {
+// INFO: incrementalの場合は全件削除せず、出力対象のAPIの行のみ削除する
+func (apiList *ApiList) Sql4Acl(path string, option AclOption, audit Audit, incremental bool) error {
+	// PROCESS: 方言の取得
+	dialect, err := NewDialect(option.Dialect)
+	if err != nil {
+		return err
+	}
+	schema := "acl"
+	if option.Schema != nil {
+		schema = *option.Schema
+	}
+	sql := aclSql{dialect: dialect, schema: schema, tables: option.Tables.withDefaults(), audit: audit}
+
 	// PROCESS: Fileの取得
 	file, cleanup, err := store.NewFile(path)
 	if err != nil {
@@ -100,18 +190,46 @@ func (apiList *ApiList) Sql4Acl(path string) error {
 	defer cleanup()
 
 	// PROCESS: 書き込み
-	file.WriteString("-- # api resource data for acl.\n")
+	file.WriteString(fmt.Sprintf("-- # api resource data for acl. (dialect: %s)\n", strings.ToLower(option.Dialect)))
+	file.WriteString(apiList.sqlHeader(audit))
 
 	file.WriteString("\n-- ----+----+----+----+----+----+----+----+----+----+----+----+----+----+----+\n\n")
-	file.WriteString("-- ## delete tables\n")
-	file.WriteString("DELETE FROM acl.api_resources;\n")
-	file.WriteString("DELETE FROM acl.resources WHERE resource_type = 'ACL';\n")
+	// INFO: 親リソース(Service/Tag)は管理者がロールを付与するため削除せず、常に更新する
+	upsert := dialect.upsert("resource_id", []string{"resource_type", "resource_name", "updated_at", "updated_by"})
+	if incremental {
+		deletes, err := apiList.aclIncrementalDeletes(sql)
+		if err != nil {
+			return err
+		}
+		file.WriteString("-- ## delete rows(incremental)\n")
+		file.WriteString(deletes)
+	} else {
+		file.WriteString("-- ## delete tables\n")
+		// INFO: リソースIDは設定ファイルで変更できるため、ID体系ではなくリソース種別で削除対象を判定する
+		file.WriteString(fmt.Sprintf("DELETE FROM %s WHERE resource_id IN (SELECT resource_id FROM %s WHERE resource_type = %s);\n",
+			sql.table(sql.tables.RoleResources), sql.table(sql.tables.Resources), dialect.literal(RESOURCE_API)))
+		file.WriteString(fmt.Sprintf("DELETE FROM %s WHERE resource_id IN (SELECT resource_id FROM %s WHERE resource_type IN (%s, %s));\n",
+			sql.table(sql.tables.ResourceRelations), sql.table(sql.tables.Resources), dialect.literal(RESOURCE_API), dialect.literal(RESOURCE_TAG)))
+		file.WriteString(fmt.Sprintf("DELETE FROM %s;\n", sql.table(sql.tables.ApiResources)))
+		file.WriteString(fmt.Sprintf("DELETE FROM %s WHERE resource_type = %s;\n", sql.table(sql.tables.Resources), dialect.literal(RESOURCE_API)))
+	}
 
 	for _, service := range apiList.Services {
 		file.WriteString("\n-- ----+----+----+----+----+----+----+----+----+----+----+----+----+----+----+\n\n")
 		file.WriteString(fmt.Sprintf("-- ## %s(%s)\n", service.ServiceName, service.openapi.description))
 
-		file.WriteString("\n-- ### Resources / ApiResources\n")
+		// PROCESS: 親リソース(Service/Tag)
+		file.WriteString("\n-- ### Resources(Service / Tag)\n")
+		parents, err := service.parentResources()
+		if err != nil {
+			return err
+		}
+		for _, resource := range parents {
+			file.WriteString(fmt.Sprintf("INSERT INTO %s VALUES (%s)%s;\n", sql.table(sql.tables.Resources),
+				sql.resourcesParam(resource.ResourceId, resource.ResourceType, resource.ResourceName), upsert))
+		}
+
+		file.WriteString("\n-- ### Resources\n")
 		for _, api := range service.openapi.apis {
 			// ApiKeyの取得
 			apiKey, err := service.getApikey(api.operationId)
@@ -119,16 +237,105 @@ func (apiList *ApiList) Sql4Acl(path string) error {
 				return err
 			}
 
-			file.WriteString(fmt.Sprintf("INSERT INTO acl.resources VALUES (%s);\n",
-				resourcesParam(apiKey.ResourceId, fmt.Sprintf("%s(%s)", api.summary, api.operationId))))
-			file.WriteString(fmt.Sprintf("INSERT INTO acl.api_resources VALUES (%s);\n",
-				apiResourcesParam(apiKey.ResourceId, apiKey.KongId)))
+			file.WriteString(fmt.Sprintf("INSERT INTO %s VALUES (%s);\n", sql.table(sql.tables.Resources),
+				sql.resourcesParam(apiKey.ResourceId, RESOURCE_API, fmt.Sprintf("%s(%s)", api.summary, api.operationId))))
+		}
+
+		// PROCESS: APIとRouteの対応(workspaceごと)
+		scopes, err := apiList.scopesOf(service)
+		if err != nil {
+			return err
+		}
+		file.WriteString("\n-- ### ApiResources\n")
+		for _, scope := range scopes {
+			for _, api := range scope.openapi.apis {
+				apiKey, err := scope.getApikey(api.operationId)
+				if err != nil {
+					return err
+				}
+				file.WriteString(fmt.Sprintf("INSERT INTO %s VALUES (%s);\n", sql.table(sql.tables.ApiResources),
+					sql.apiResourcesParam(apiKey.ResourceId, apiKey.KongId)))
+			}
+		}
+
+		// PROCESS: リソースの親子関係
+		relations, err := service.resourceRelations()
+		if err != nil {
+			return err
+		}
+		file.WriteString("\n-- ### ResourceRelations\n")
+		for _, relation := range relations {
+			file.WriteString(fmt.Sprintf("INSERT INTO %s VALUES (%s);\n", sql.table(sql.tables.ResourceRelations),
+				sql.resourceRelationsParam(relation)))
+		}
+
+		// PROCESS: ロールへの権限付与
+		grants := []string{}
+		for _, api := range service.openapi.apis {
+			apiKey, err := service.getApikey(api.operationId)
+			if err != nil {
+				return err
+			}
+			for _, role := range apiList.apiRoles(api) {
+				grants = append(grants, fmt.Sprintf("INSERT INTO %s VALUES (%s);\n", sql.table(sql.tables.RoleResources),
+					sql.roleResourcesParam(role, apiKey.ResourceId)))
+			}
+		}
+		if len(grants) > 0 {
+			file.WriteString("\n-- ### RoleResources\n")
+			for _, grant := range grants {
+				file.WriteString(grant)
+			}
 		}
 	}
 
 	return nil
 }
 
+// FUNCTION: 差分更新用の削除文(出力対象のRoute/Plugin/Target)
+// INFO: Service/Upstreamは対象外のRouteから参照されるため削除せず、INSERT時に更新する
+func kongIncrementalDeletes(scopes []scopedService, mockHeader *MockHeader) (string, error) {
+	routeIds := []string{}
+	serviceIds := []string{}
+	upstreamIds := []string{}
+	for _, scope := range scopes {
+		routes, err := scope.routes(mockHeader)
+		if err != nil {
+			return "", err
+		}
+		for _, route := range routes {
+			routeIds = append(routeIds, route.Id)
+		}
+		serviceIds = append(serviceIds, scope.ProdServer.ServiceId, scope.MockServer.ServiceId)
+		for _, upstream := range scope.upstreams() {
+			upstreamIds = append(upstreamIds, upstream.Id)
+		}
+	}
+
+	deletes := ""
+	if len(routeIds) > 0 {
+		deletes += fmt.Sprintf("DELETE FROM plugin WHERE route_id IN (%s);\n", sqlArray(routeIds))
+	}
+	if len(serviceIds) > 0 {
+		deletes += fmt.Sprintf("DELETE FROM plugin WHERE service_id IN (%s) AND route_id IS NULL;\n", sqlArray(serviceIds))
+	}
+	if len(routeIds) > 0 {
+		deletes += fmt.Sprintf("DELETE FROM route WHERE id IN (%s);\n", sqlArray(routeIds))
+	}
+	if len(upstreamIds) > 0 {
+		deletes += fmt.Sprintf("DELETE FROM target WHERE upstream_id IN (%s);\n", sqlArray(upstreamIds))
+	}
+	return deletes, nil
+}
+
+// FUNCTION: 差分更新時のINSERT(既存行は指定列を更新する)
+func upsertClause(incremental bool, columns ...string) string {
+	if !incremental {
+		return ""
+	}
+	return onConflict("id", append(columns, "updated_at"))
+}
+
 // FUNCTION: serviceParams
 func serviceParam(serviceId string, description string, host string, port int, tag string, wsId string) string {
 	return fmt.Sprintf("'%s', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, '%s', 5, 'http', '%s', %d, null, 60000, 60000, 60000, ARRAY[%s], null, null, null, null, '%s', true",
@@ -142,40 +349,181 @@ func serviceParam(serviceId string, description string, host string, port int, t
 }
 
 // FUNCTION: routeParams
-func routeParams(serviceName string, kongId string, summary string, operationId string, serviceId string, method string, path string, tag string, wsId string) string {
-	return fmt.Sprintf("'%s', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, '%s', '%s', ARRAY['http', 'https'], ARRAY['%s'], null, '(\"%s\")', null, null, null, 0, false, false, ARRAY[%s], 426, null, 'v0', '%s', true, true, null, null",
+func routeParams(kongId string, name string, serviceId string, method string, regex string, priority int, tag string, headers string, wsId string) string {
+	return fmt.Sprintf("'%s', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, '%s', '%s', ARRAY['http', 'https'], ARRAY['%s'], null, '(\"%s\")', null, null, null, %d, false, false, ARRAY[%s], 426, %s, 'v0', '%s', true, true, null, null",
 		kongId,
-		fmt.Sprintf("%s(%s)", summary, operationId),
+		name,
 		serviceId,
 		method,
-		fmt.Sprintf("~/%s%s", serviceName, re.ReplaceAllString(path, `[A-Za-z0-9_-]+`)),
+		fmt.Sprintf("~%s", regex),
+		priority,
+		tag,
+		headers,
+		wsId,
+	)
+}
+
+// FUNCTION: pluginParams
+func pluginParams(plugin KongPlugin, tag string, wsId string) string {
+	return fmt.Sprintf("'%s', CURRENT_TIMESTAMP, '%s', null, %s, %s, '%s', true, '%s', ARRAY['grpc', 'grpcs', 'http', 'https'], ARRAY[%s], '%s', null, CURRENT_TIMESTAMP",
+		plugin.Id,
+		plugin.Name,
+		nullableId(plugin.ServiceId),
+		nullableId(plugin.RouteId),
+		strings.ReplaceAll(plugin.configJson(), "'", "''"),
+		fmt.Sprintf("plugins:%s:%s:%s:::%s", plugin.Name, plugin.RouteId, plugin.ServiceId, wsId),
 		tag,
 		wsId,
 	)
 }
 
+// FUNCTION: idまたはnull
+func nullableId(id string) string {
+	if id == "" {
+		return "null"
+	}
+	return fmt.Sprintf("'%s'", id)
+}
+
+// FUNCTION: upstreamParams
+func upstreamParams(upstream Upstream, tag string, wsId string) string {
+	return fmt.Sprintf("'%s', CURRENT_TIMESTAMP, '%s', 'none', 'none', null, null, 'cookie', '/', 10000, '%s', ARRAY[%s], 'round-robin', null, null, '%s', null, null, null, null, false, CURRENT_TIMESTAMP",
+		upstream.Id,
+		upstream.Name,
+		strings.ReplaceAll(upstream.healthchecksJson(), "'", "''"),
+		tag,
+		wsId,
+	)
+}
+
+// FUNCTION: targetParams
+func targetParams(upstream Upstream, target Target, tag string, wsId string) string {
+	return fmt.Sprintf("'%s', CURRENT_TIMESTAMP, '%s', '%s', %d, ARRAY[%s], '%s', '%s', CURRENT_TIMESTAMP",
+		upstream.targetId(target),
+		upstream.Id,
+		target.Target,
+		target.weight(),
+		tag,
+		wsId,
+		fmt.Sprintf("targets:%s:%s::::%s", upstream.Id, target.Target, wsId),
+	)
+}
+
+// FUNCTION: headers列(jsonb)
+func (route Route) headersJson() string {
+	if len(route.Headers) == 0 {
+		return "null"
+	}
+	headers := map[string][]string{}
+	for name, value := range route.Headers {
+		headers[name] = []string{value}
+	}
+	value, _ := json.Marshal(headers)
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(string(value), "'", "''"))
+}
+
+// FUNCTION: ARRAYの要素
+func sqlArray(values []string) string {
+	items := []string{}
+	for _, value := range values {
+		items = append(items, fmt.Sprintf("'%s'", value))
+	}
+	return strings.Join(items, ", ")
+}
+
+// FUNCTION: 差分更新用の削除文(出力対象のAPIのリソースとTagの親子関係)
+func (apiList *ApiList) aclIncrementalDeletes(sql aclSql) (string, error) {
+	apiIds := []string{}
+	tagIds := []string{}
+	for _, service := range apiList.Services {
+		for _, api := range service.openapi.apis {
+			apiKey, err := service.getApikey(api.operationId)
+			if err != nil {
+				return "", err
+			}
+			apiIds = append(apiIds, sql.dialect.literal(apiKey.ResourceId))
+		}
+		for _, tag := range service.openapi.tags {
+			tagKey, err := service.getTagKey(tag.name)
+			if err != nil {
+				return "", err
+			}
+			tagIds = append(tagIds, sql.dialect.literal(tagKey.ResourceId))
+		}
+	}
+	if len(apiIds) == 0 {
+		return "", nil
+	}
+
+	apis := strings.Join(apiIds, ", ")
+	deletes := fmt.Sprintf("DELETE FROM %s WHERE resource_id IN (%s);\n", sql.table(sql.tables.RoleResources), apis)
+	deletes += fmt.Sprintf("DELETE FROM %s WHERE resource_id IN (%s);\n", sql.table(sql.tables.ResourceRelations), strings.Join(append(apiIds, tagIds...), ", "))
+	deletes += fmt.Sprintf("DELETE FROM %s WHERE resource_id IN (%s);\n", sql.table(sql.tables.ApiResources), apis)
+	deletes += fmt.Sprintf("DELETE FROM %s WHERE resource_id IN (%s);\n", sql.table(sql.tables.Resources), apis)
+	return deletes, nil
+}
+
+// TITLE: ACL用SQLの組み立て(方言・スキーマ・テーブル名)
+type aclSql struct {
+	dialect Dialect
+	schema  string
+	tables  AclTables
+	audit   Audit
+}
+
+// FUNCTION: テーブル名
+func (sql aclSql) table(name string) string {
+	return sql.dialect.table(sql.schema, name)
+}
+
 // FUNCTION: resourcesParam
-func resourcesParam(recourceId string, apiName string) string {
-	return fmt.Sprintf("'%s', 'API', '%s', %s",
-		recourceId,
-		apiName,
-		traceColumns(),
+func (sql aclSql) resourcesParam(recourceId string, resourceType string, resourceName string) string {
+	return fmt.Sprintf("%s, %s, %s, %s",
+		sql.dialect.literal(recourceId),
+		sql.dialect.literal(resourceType),
+		sql.dialect.literal(resourceName),
+		sql.traceColumns(),
 	)
 }
 
 // FUNCTION: api_resourcesParam
-func apiResourcesParam(recourceId string, kongId string) string {
-	return fmt.Sprintf("'%s', '%s', %s",
-		recourceId,
-		kongId,
-		traceColumns(),
+func (sql aclSql) apiResourcesParam(recourceId string, kongId string) string {
+	return fmt.Sprintf("%s, %s, %s",
+		sql.dialect.literal(recourceId),
+		sql.dialect.literal(kongId),
+		sql.traceColumns(),
+	)
+}
+
+// FUNCTION: resource_relationsParam
+func (sql aclSql) resourceRelationsParam(relation AclRelation) string {
+	return fmt.Sprintf("%s, %s, %s",
+		sql.dialect.literal(relation.ResourceId),
+		sql.dialect.literal(relation.ParentResourceId),
+		sql.traceColumns(),
+	)
+}
+
+// FUNCTION: role_resourcesParam
+func (sql aclSql) roleResourcesParam(roleName string, recourceId string) string {
+	return fmt.Sprintf("%s, %s, %s",
+		sql.dialect.literal(roleName),
+		sql.dialect.literal(recourceId),
+		sql.traceColumns(),
 	)
 }
 
 // FUNCTION: traceColumns
-func traceColumns() string {
-	return fmt.Sprintf("CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, '%s', '%s'",
-		TRACE_ID,
-		TRACE_ID,
+// INFO: 日時を指定しない場合はDBの現在日時
+func (sql aclSql) traceColumns() string {
+	timestamp := sql.dialect.now()
+	if sql.audit.Timestamp != nil {
+		timestamp = sql.dialect.timestamp(*sql.audit.Timestamp)
+	}
+	return fmt.Sprintf("%s, %s, %s, %s",
+		timestamp,
+		timestamp,
+		sql.dialect.literal(sql.audit.traceId()),
+		sql.dialect.literal(sql.audit.traceId()),
 	)
 }
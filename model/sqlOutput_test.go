@@ -0,0 +1,94 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// ACLテーブル(sqlite)
+const aclSchemaSqlite = `
+CREATE TABLE resources (resource_id TEXT PRIMARY KEY, resource_type TEXT, resource_name TEXT, created_at TEXT, updated_at TEXT, created_by TEXT, updated_by TEXT);
+CREATE TABLE api_resources (resource_id TEXT, kong_id TEXT, created_at TEXT, updated_at TEXT, created_by TEXT, updated_by TEXT, PRIMARY KEY (resource_id, kong_id));
+CREATE TABLE resource_relations (resource_id TEXT, parent_resource_id TEXT, created_at TEXT, updated_at TEXT, created_by TEXT, updated_by TEXT, PRIMARY KEY (resource_id, parent_resource_id));
+CREATE TABLE role_resources (role_name TEXT, resource_id TEXT, created_at TEXT, updated_at TEXT, created_by TEXT, updated_by TEXT, PRIMARY KEY (role_name, resource_id));
+`
+
+// FUNCTION: 生成したACLのSQL(sqlite)を同じDBに2回適用できること
+// INFO: `API-`で始まらないリソースIDを設定ファイルで指定した場合も含む
+func TestSql4AclApplyTwice(t *testing.T) {
+	for name, resourceIds := range map[string][]string{
+		"generated": nil,
+		"custom":    {"ITEM-LIST", "ITEM-CREATE"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			service := newTestService(t, "sample", taggedSpec)
+			for i, resourceId := range resourceIds {
+				service.Apis[i].ResourceId = resourceId
+			}
+			apiList := ApiList{Roles: []Role{{Name: "admin", Apis: []string{"*"}}}, Services: []Service{service}}
+			testSql4AclApplyTwice(t, &apiList)
+		})
+	}
+}
+
+func testSql4AclApplyTwice(t *testing.T, apiList *ApiList) {
+	dir := t.TempDir()
+
+	// PROCESS: SQL出力
+	schema := ""
+	path := filepath.Join(dir, "aclData.sql")
+	if err := apiList.Sql4Acl(path, AclOption{Dialect: "sqlite", Schema: &schema}, Audit{}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// PROCESS: DBの準備
+	db, err := OpenDatabase("sqlite://" + filepath.Join(dir, "acl.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(aclSchemaSqlite); err != nil {
+		t.Fatal(err)
+	}
+
+	// PROCESS: 2回適用(間に管理者がServiceへロールを付与する)
+	for i := range 2 {
+		result, err := ApplySql(db, path, false)
+		if err != nil {
+			t.Fatalf("apply #%d: %v", i+1, err)
+		}
+		if !result.Committed {
+			t.Fatalf("apply #%d: not committed", i+1)
+		}
+		if i == 0 {
+			_, err := db.Exec("INSERT INTO role_resources VALUES ('operator', ?, '', '', '', '')", apiList.Services[0].ResourceId)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// PROCESS: 件数の確認(API2件+Service1件+Tag1件)
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM resources").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("resources = %d, want 4", count)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM role_resources").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("role_resources = %d, want 3 (service grant must be kept)", count)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM resource_relations").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Errorf("resource_relations = %d, want 5", count)
+	}
+}
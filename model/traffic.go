@@ -0,0 +1,300 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/koron/go-dproxy"
+)
+
+// TITLE: RateLimit構造体(`x-rate-limit`)
+// INFO: 上位設定を0(制限なし)で上書きできるよう、未指定(nil)と区別する
+type RateLimit struct {
+	second *int
+	minute *int
+	hour   *int
+	day    *int
+}
+
+// TITLE: Cors構造体(`x-cors`)
+// INFO: 上位設定を空・falseで上書きできるよう、未指定(nil)と区別する
+type Cors struct {
+	defined        bool
+	origins        []string
+	methods        []string
+	headers        []string
+	exposedHeaders []string
+	credentials    *bool
+	maxAge         *int
+}
+
+// FUNCTION: RateLimitのパース
+func newRateLimit(p dproxy.Proxy) RateLimit {
+	return RateLimit{
+		second: optionalInt(p.M("second")),
+		minute: optionalInt(p.M("minute")),
+		hour:   optionalInt(p.M("hour")),
+		day:    optionalInt(p.M("day")),
+	}
+}
+
+// FUNCTION: Corsのパース
+func newCors(p dproxy.Proxy) Cors {
+	if _, err := p.Map(); err != nil {
+		return Cors{}
+	}
+	cors := Cors{
+		defined:        true,
+		origins:        optionalStringArray(p.M("origins")),
+		methods:        optionalStringArray(p.M("methods")),
+		headers:        optionalStringArray(p.M("headers")),
+		exposedHeaders: optionalStringArray(p.M("exposedHeaders")),
+		maxAge:         optionalInt(p.M("maxAge")),
+	}
+	if credentials, err := p.M("credentials").Bool(); err == nil {
+		cors.credentials = &credentials
+	}
+	return cors
+}
+
+// FUNCTION: 整数の取得(未指定の場合はnil)
+func optionalInt(p dproxy.Proxy) *int {
+	value, err := p.Int64()
+	if err != nil {
+		return nil
+	}
+	result := int(value)
+	return &result
+}
+
+// FUNCTION: 整数の値(未指定の場合は0)
+func intValue(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// FUNCTION: 文字列配列の取得(未指定の場合はnil)
+func optionalStringArray(p dproxy.Proxy) []string {
+	if _, err := p.Array(); err != nil {
+		return nil
+	}
+	return stringArray(p)
+}
+
+// FUNCTION: 文字列配列の取得
+func stringArray(p dproxy.Proxy) []string {
+	items, _ := p.Array()
+	result := []string{}
+	for _, item := range items {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+// FUNCTION: 設定の有無(0の指定を含む)
+func (limit RateLimit) defined() bool {
+	return limit.second != nil || limit.minute != nil || limit.hour != nil || limit.day != nil
+}
+
+// FUNCTION: 制限の有無(1以上の項目がある)
+func (limit RateLimit) enabled() bool {
+	return intValue(limit.second) > 0 || intValue(limit.minute) > 0 || intValue(limit.hour) > 0 || intValue(limit.day) > 0
+}
+
+// FUNCTION: 上位設定への上書き(指定された項目のみ上書きする)
+func (limit RateLimit) merge(override RateLimit) RateLimit {
+	if override.second != nil {
+		limit.second = override.second
+	}
+	if override.minute != nil {
+		limit.minute = override.minute
+	}
+	if override.hour != nil {
+		limit.hour = override.hour
+	}
+	if override.day != nil {
+		limit.day = override.day
+	}
+	return limit
+}
+
+// FUNCTION: 表示用文字列
+func (limit RateLimit) String() string {
+	if !limit.enabled() {
+		return "-"
+	}
+	ls := []string{}
+	for _, item := range []struct {
+		value *int
+		unit  string
+	}{{limit.second, "sec"}, {limit.minute, "min"}, {limit.hour, "hour"}, {limit.day, "day"}} {
+		if intValue(item.value) > 0 {
+			ls = append(ls, fmt.Sprintf("%d/%s", *item.value, item.unit))
+		}
+	}
+	return strings.Join(ls, ", ")
+}
+
+// FUNCTION: 上位設定への上書き(指定された項目のみ上書きする)
+func (cors Cors) merge(override Cors) Cors {
+	if !override.defined {
+		return cors
+	}
+	cors.defined = true
+	if override.origins != nil {
+		cors.origins = override.origins
+	}
+	if override.methods != nil {
+		cors.methods = override.methods
+	}
+	if override.headers != nil {
+		cors.headers = override.headers
+	}
+	if override.exposedHeaders != nil {
+		cors.exposedHeaders = override.exposedHeaders
+	}
+	if override.credentials != nil {
+		cors.credentials = override.credentials
+	}
+	if override.maxAge != nil {
+		cors.maxAge = override.maxAge
+	}
+	return cors
+}
+
+// FUNCTION: rate-limiting Plugin
+func (limit RateLimit) plugin() KongPlugin {
+	return KongPlugin{
+		Name: "rate-limiting",
+		Config: map[string]interface{}{
+			"second":              nullableInt(intValue(limit.second)),
+			"minute":              nullableInt(intValue(limit.minute)),
+			"hour":                nullableInt(intValue(limit.hour)),
+			"day":                 nullableInt(intValue(limit.day)),
+			"month":               nil,
+			"year":                nil,
+			"limit_by":            "consumer",
+			"policy":              "local",
+			"fault_tolerant":      true,
+			"hide_client_headers": false,
+			"error_code":          429,
+			"error_message":       "API rate limit exceeded",
+		},
+	}
+}
+
+// FUNCTION: cors Plugin
+func (cors Cors) plugin() KongPlugin {
+	return KongPlugin{
+		Name: "cors",
+		Config: map[string]interface{}{
+			"origins":            nullableArray(cors.origins),
+			"methods":            nullableArray(cors.methods),
+			"headers":            nullableArray(cors.headers),
+			"exposed_headers":    nullableArray(cors.exposedHeaders),
+			"credentials":        cors.credentials != nil && *cors.credentials,
+			"max_age":            nullableInt(intValue(cors.maxAge)),
+			"preflight_continue": false,
+			"private_network":    false,
+		},
+	}
+}
+
+// FUNCTION: 未指定(0)の場合はnull
+func nullableInt(value int) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// FUNCTION: 未指定(空)の場合はnull
+func nullableArray(values []string) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// FUNCTION: Rate-Limit/CORSのPluginを作成
+// INFO: ルートの設定はServiceに、パス・オペレーションの設定はRouteに紐づける(Kongでは後者が優先される)
+// INFO: 全項目を0で上書き(制限を解除)するAPIがある場合、Kongでは無効化できないため、rate-limitingはServiceではなく解除しない全Routeに紐づける
+func (service *Service) trafficPlugins(routes []Route) []KongPlugin {
+	openapi := service.openapi
+	plugins := []KongPlugin{}
+	optOut := slices.ContainsFunc(routes, func(route Route) bool {
+		return route.api.rateLimit.defined() && !route.api.effectiveRateLimit(&openapi).enabled()
+	})
+	serviceRateLimit := openapi.rateLimit
+	if optOut {
+		serviceRateLimit = RateLimit{}
+	}
+
+	// PROCESS: Service単位
+	for _, server := range []Server{service.ProdServer, service.MockServer} {
+		for _, plugin := range newTrafficPlugins(serviceRateLimit, openapi.cors) {
+			plugin.Id = deriveId(server.ServiceId, plugin.Name)
+			plugin.ServiceId = server.ServiceId
+			plugins = append(plugins, plugin)
+		}
+	}
+
+	// PROCESS: Route単位(上位の設定とマージした値を設定する)
+	for _, route := range routes {
+		api := route.api
+		rateLimit := RateLimit{}
+		if api.rateLimit.defined() || optOut {
+			rateLimit = api.effectiveRateLimit(&openapi)
+		}
+		cors := Cors{}
+		if api.cors.defined {
+			cors = openapi.cors.merge(api.cors)
+		}
+		for _, plugin := range newTrafficPlugins(rateLimit, cors) {
+			plugin.Id = deriveId(route.Id, plugin.Name)
+			plugin.RouteId = route.Id
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+// FUNCTION: 設定済みのRate-Limit/CORSのPlugin
+func newTrafficPlugins(rateLimit RateLimit, cors Cors) []KongPlugin {
+	plugins := []KongPlugin{}
+	if rateLimit.enabled() {
+		plugins = append(plugins, rateLimit.plugin())
+	}
+	if cors.defined {
+		plugins = append(plugins, cors.plugin())
+	}
+	return plugins
+}
+
+// FUNCTION: Rate-Limit/CORSに対応しない出力で無視される設定の警告
+func (apiList *ApiList) warnTrafficIgnored(output string) {
+	for _, service := range apiList.Services {
+		openapi := service.openapi
+		ignored := openapi.rateLimit.enabled() || openapi.cors.defined
+		for _, api := range openapi.apis {
+			ignored = ignored || api.rateLimit.enabled() || api.cors.defined
+		}
+		if ignored {
+			log.Printf("WARNING: %s output ignores x-rate-limit/x-cors of '%s'.", output, service.ServiceName)
+		}
+	}
+}
+
+// FUNCTION: 実際に適用されるRate-Limit
+func (api *Api) effectiveRateLimit(openapi *Openapi) RateLimit {
+	return openapi.rateLimit.merge(api.rateLimit)
+}
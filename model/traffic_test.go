@@ -0,0 +1,106 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// ルートの設定をオペレーションで0/falseに上書きするスキーマ
+const trafficSpec = `
+openapi: 3.0.3
+info:
+  title: Traffic
+  version: 1.0.0
+x-rate-limit:
+  minute: 60
+  hour: 1000
+x-cors:
+  origins: ['*']
+  credentials: true
+paths:
+  /items:
+    get:
+      operationId: items.get
+      x-rate-limit:
+        minute: 0
+        hour: 0
+      x-cors:
+        credentials: false
+      responses:
+        '200':
+          description: OK
+    post:
+      operationId: items.post
+      x-rate-limit:
+        minute: 0
+      responses:
+        '200':
+          description: OK
+`
+
+// FUNCTION: 0/falseの明示的な上書きを反映すること
+func TestTrafficExplicitOverride(t *testing.T) {
+	openapi, err := parseOpenapi([]byte(trafficSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := Service{
+		ServiceName: "sample",
+		openapi:     *openapi,
+		ProdServer:  Server{ServiceId: uuid.NewString()},
+		MockServer:  Server{ServiceId: uuid.NewString()},
+	}
+	for _, api := range openapi.apis {
+		service.Apis = append(service.Apis, ApiKey{OperationId: api.operationId, KongId: uuid.NewString(), Implemented: true})
+	}
+
+	// PROCESS: 実際に適用されるRate-Limit
+	limits := map[string]string{}
+	for _, api := range openapi.apis {
+		limits[api.operationId] = api.effectiveRateLimit(openapi).String()
+	}
+	if limits["items.get"] != "-" || limits["items.post"] != "1000/hour" {
+		t.Errorf("rate limits = %v", limits)
+	}
+
+	// PROCESS: Plugin
+	routes, err := service.routes(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugins := service.trafficPlugins(routes)
+	for _, route := range routes {
+		// INFO: Kongと同様にRoute単位のPluginをService単位より優先して解決する
+		effective := map[string]KongPlugin{}
+		for _, plugin := range plugins {
+			if plugin.ServiceId == route.Server.ServiceId {
+				if _, ok := effective[plugin.Name]; !ok {
+					effective[plugin.Name] = plugin
+				}
+			}
+		}
+		for _, plugin := range plugins {
+			if plugin.RouteId == route.Id {
+				effective[plugin.Name] = plugin
+			}
+		}
+
+		switch route.ApiKey.OperationId {
+		case "items.get":
+			if plugin, ok := effective["rate-limiting"]; ok {
+				t.Errorf("items.get must not be rate-limited: %v", plugin.Config)
+			}
+			if cors := effective["cors"]; cors.Config["credentials"] != false || cors.Config["origins"] == nil {
+				t.Errorf("cors config = %v", cors.Config)
+			}
+		case "items.post":
+			if limit := effective["rate-limiting"]; limit.Config["hour"] != 1000 || limit.Config["minute"] != nil {
+				t.Errorf("rate-limiting config = %v", limit.Config)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Targetのデフォルトの重み
+const DEFAULT_WEIGHT = 100
+
+// TITLE: Upstream構造体
+type Upstream struct {
+	Id         string
+	Name       string
+	HealthPath string
+	Targets    []Target
+}
+
+// FUNCTION: targetsが指定されたServerのUpstream
+func (service *Service) upstreams() []Upstream {
+	healthPath := service.healthPath()
+	upstreams := []Upstream{}
+	for i, server := range []Server{service.ProdServer, service.MockServer} {
+		if len(server.Targets) == 0 {
+			continue
+		}
+		upstreams = append(upstreams, Upstream{
+			Id:         server.upstreamId(),
+			Name:       upstreamName(service.ServiceName, i == 1),
+			HealthPath: healthPath,
+			Targets:    server.Targets,
+		})
+	}
+	return upstreams
+}
+
+// FUNCTION: ヘルスチェック用のAPI(`x-health-check: true`)のパス
+func (service *Service) healthPath() string {
+	for _, api := range service.openapi.apis {
+		if api.healthCheck && api.method == "get" {
+			return fmt.Sprintf("/%s%s", service.ServiceName, api.path)
+		}
+	}
+	return ""
+}
+
+// FUNCTION: Upstream名称(serviceのhostとして使用する)
+func upstreamName(serviceName string, isMock bool) string {
+	if isMock {
+		return fmt.Sprintf("%s.mock.upstream", serviceName)
+	}
+	return fmt.Sprintf("%s.prod.upstream", serviceName)
+}
+
+// FUNCTION: Kongのserviceに設定するhost
+func (server Server) kongHost(serviceName string, isMock bool) string {
+	if len(server.Targets) > 0 {
+		return upstreamName(serviceName, isMock)
+	}
+	return server.Host
+}
+
+// FUNCTION: UpstreamId
+func (server Server) upstreamId() string {
+	return deriveId(server.ServiceId, "upstream")
+}
+
+// FUNCTION: TargetId
+func (upstream Upstream) targetId(target Target) string {
+	return deriveId(upstream.Id, target.Target)
+}
+
+// FUNCTION: 重み(未指定の場合はデフォルト値)
+func (target Target) weight() int {
+	if target.Weight == 0 {
+		return DEFAULT_WEIGHT
+	}
+	return target.Weight
+}
+
+// FUNCTION: host/portの分割(portが無い場合は80、IPv6は`[::1]:8080`の形式)
+func (target Target) hostPort() (string, int, error) {
+	host, port, err := net.SplitHostPort(target.Target)
+	if err != nil {
+		var addrErr *net.AddrError
+		if errors.As(err, &addrErr) && addrErr.Err == "missing port in address" {
+			return strings.TrimSuffix(strings.TrimPrefix(target.Target, "["), "]"), 80, nil
+		}
+		return "", 0, fmt.Errorf("invalid target '%s': %w", target.Target, err)
+	}
+	value, err := strconv.Atoi(port)
+	if err != nil || value < 1 || value > 65535 {
+		return "", 0, fmt.Errorf("invalid target '%s': port must be 1-65535", target.Target)
+	}
+	return host, value, nil
+}
+
+// FUNCTION: targetsのチェック
+func (server Server) checkTargets() error {
+	for _, target := range server.Targets {
+		if _, _, err := target.hostPort(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FUNCTION: healthchecks列のJSON
+// INFO: ヘルスチェック用のAPIが無い場合、アクティブヘルスチェックは無効(interval=0)とする
+func (upstream Upstream) healthchecksJson() string {
+	interval := 0
+	httpPath := "/"
+	if upstream.HealthPath != "" {
+		interval = 5
+		httpPath = upstream.HealthPath
+	}
+	healthchecks := map[string]interface{}{
+		"threshold": 0,
+		"active": map[string]interface{}{
+			"type":                     "http",
+			"http_path":                httpPath,
+			"timeout":                  1,
+			"concurrency":              10,
+			"https_verify_certificate": true,
+			"https_sni":                nil,
+			"headers":                  nil,
+			"healthy": map[string]interface{}{
+				"interval":      interval,
+				"successes":     2,
+				"http_statuses": []int{200, 302},
+			},
+			"unhealthy": map[string]interface{}{
+				"interval":      interval,
+				"http_failures": 3,
+				"tcp_failures":  3,
+				"timeouts":      3,
+				"http_statuses": []int{429, 404, 500, 501, 502, 503, 504, 505},
+			},
+		},
+		"passive": map[string]interface{}{
+			"type": "http",
+			"healthy": map[string]interface{}{
+				"successes":     0,
+				"http_statuses": []int{200, 201, 202, 203, 204, 205, 206, 207, 208, 226, 300, 301, 302, 303, 304, 305, 306, 307, 308},
+			},
+			"unhealthy": map[string]interface{}{
+				"http_failures": 0,
+				"tcp_failures":  0,
+				"timeouts":      0,
+				"http_statuses": []int{429, 500, 503},
+			},
+		},
+	}
+	value, _ := json.Marshal(healthchecks)
+	return string(value)
+}
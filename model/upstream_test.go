@@ -0,0 +1,84 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+// FUNCTION: targetのhost/portの分割(IPv6、port省略、不正なport)
+func TestTargetHostPort(t *testing.T) {
+	tests := []struct {
+		target string
+		host   string
+		port   int
+		err    bool
+	}{
+		{"orders.local:8080", "orders.local", 8080, false},
+		{"orders.local", "orders.local", 80, false},
+		{"[::1]:8080", "::1", 8080, false},
+		{"[fe80::1]", "fe80::1", 80, false},
+		{"orders.local:80a", "", 0, true},
+		{"orders.local:0", "", 0, true},
+		{"::1:8080", "", 0, true},
+	}
+	for _, tt := range tests {
+		host, port, err := Target{Target: tt.target}.hostPort()
+		if (err != nil) != tt.err || host != tt.host || port != tt.port {
+			t.Errorf("hostPort(%s) = %s, %d, %v", tt.target, host, port, err)
+		}
+	}
+}
+
+// ヘルスチェック用のAPIを持つスキーマ
+const healthSpec = `
+openapi: 3.0.3
+info:
+  title: Health
+  version: 1.0.0
+paths:
+  /health:
+    get:
+      operationId: health.get
+      x-health-check: true
+      responses:
+        '200':
+          description: OK
+  /items:
+    get:
+      operationId: items.get
+      responses:
+        '200':
+          description: OK
+`
+
+// FUNCTION: targetsを指定したServerのみUpstreamを作成し、ヘルスチェック用のAPIを監視すること
+func TestUpstreams(t *testing.T) {
+	service := newTestService(t, "sample", healthSpec)
+	service.ProdServer.Targets = []Target{{Target: "sample-1:8080"}, {Target: "sample-2:8080", Weight: 10}}
+
+	upstreams := service.upstreams()
+	if len(upstreams) != 1 {
+		t.Fatalf("upstreams = %d, want 1 (prod only)", len(upstreams))
+	}
+	upstream := upstreams[0]
+	if upstream.Name != "sample.prod.upstream" || upstream.HealthPath != "/sample/health" {
+		t.Errorf("upstream = %s %s", upstream.Name, upstream.HealthPath)
+	}
+	if weights := []int{upstream.Targets[0].weight(), upstream.Targets[1].weight()}; weights[0] != DEFAULT_WEIGHT || weights[1] != 10 {
+		t.Errorf("weights = %v", weights)
+	}
+	if !strings.Contains(upstream.healthchecksJson(), `"http_path":"/sample/health"`) {
+		t.Errorf("healthchecks = %s", upstream.healthchecksJson())
+	}
+
+	// PROCESS: serviceのhost
+	if host := service.ProdServer.kongHost(service.ServiceName, false); host != "sample.prod.upstream" {
+		t.Errorf("prod host = %s", host)
+	}
+	if host := service.MockServer.kongHost(service.ServiceName, true); host != "sample-mock" {
+		t.Errorf("mock host = %s", host)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"fmt"
+	"slices"
+)
+
+// TITLE: Workspace構造体(api-setup.yamlの`workspaces`)
+type Workspace struct {
+	Name string `yaml:"name"`
+	Id   string `yaml:"id"`
+}
+
+// TITLE: workspace単位のService
+// INFO: ServiceId/KongIdはworkspaceごとに基準IDから派生させる(workSpaceIdと同じworkspaceは基準IDのまま)
+type scopedService struct {
+	Service
+	workspaceId   string
+	workspaceName string
+}
+
+// FUNCTION: workspace単位のServiceの一覧
+func (apiList *ApiList) scopedServices() ([]scopedService, error) {
+	scopes := []scopedService{}
+	for _, service := range apiList.Services {
+		items, err := apiList.scopesOf(service)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, items...)
+	}
+	return scopes, nil
+}
+
+// FUNCTION: Serviceが属するworkspaceごとのService
+// INFO: workspacesを指定しないServiceはworkSpaceIdに属する
+func (apiList *ApiList) scopesOf(service Service) ([]scopedService, error) {
+	if len(service.Workspaces) == 0 {
+		return []scopedService{{Service: service, workspaceId: apiList.WorkSpaceId}}, nil
+	}
+	scopes := []scopedService{}
+	for _, name := range service.Workspaces {
+		workspace, err := apiList.workspace(name)
+		if err != nil {
+			return nil, fmt.Errorf("service '%s': %w", service.ServiceName, err)
+		}
+		scopes = append(scopes, scopedService{
+			Service:       service.scoped(workspace.Id, workspace.Id != apiList.WorkSpaceId),
+			workspaceId:   workspace.Id,
+			workspaceName: workspace.Name,
+		})
+	}
+	return scopes, nil
+}
+
+// FUNCTION: workspaceの取得
+func (apiList *ApiList) workspace(name string) (Workspace, error) {
+	for _, workspace := range apiList.Workspaces {
+		if workspace.Name != name {
+			continue
+		}
+		if workspace.Id == "" {
+			return Workspace{}, fmt.Errorf("workspace '%s' has no id", name)
+		}
+		return workspace, nil
+	}
+	return Workspace{}, fmt.Errorf("workspace '%s' is not defined", name)
+}
+
+// FUNCTION: workspace用に各IDを派生させたService
+func (service Service) scoped(workspaceId string, derive bool) Service {
+	if !derive {
+		return service
+	}
+	service.ProdServer.ServiceId = deriveId(service.ProdServer.ServiceId, workspaceId)
+	service.MockServer.ServiceId = deriveId(service.MockServer.ServiceId, workspaceId)
+	service.Apis = slices.Clone(service.Apis)
+	for i := range service.Apis {
+		service.Apis[i].KongId = deriveId(service.Apis[i].KongId, workspaceId)
+	}
+	return service
+}
+
+// FUNCTION: SQLのセクション見出し
+func (scope scopedService) title() string {
+	if scope.workspaceName == "" {
+		return fmt.Sprintf("%s(%s)", scope.ServiceName, scope.openapi.description)
+	}
+	return fmt.Sprintf("%s(%s) @%s", scope.ServiceName, scope.openapi.description, scope.workspaceName)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2024 Teruaki Sato <andrea.pirlo.0529@gmail.com>
+*/
+package model
+
+import (
+	"testing"
+)
+
+// FUNCTION: workspaceごとにIDを派生させ、既定のworkspaceは基準IDのままとすること
+func TestScopedServices(t *testing.T) {
+	service := newTestService(t, "sample", taggedSpec)
+	service.Workspaces = []string{"default", "tenant-a"}
+	apiList := ApiList{
+		WorkSpaceId: "42213eb3-e653-42a3-b207-bb81c7e75547",
+		Workspaces: []Workspace{
+			{Name: "default", Id: "42213eb3-e653-42a3-b207-bb81c7e75547"},
+			{Name: "tenant-a", Id: "9b1d2c3e-0000-4000-8000-000000000001"},
+		},
+		Services: []Service{service, newTestService(t, "other", samePathSpec)},
+	}
+
+	scopes, err := apiList.scopedServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scopes) != 3 {
+		t.Fatalf("scopes = %d, want 3", len(scopes))
+	}
+	base, tenant, other := scopes[0], scopes[1], scopes[2]
+	if base.ProdServer.ServiceId != service.ProdServer.ServiceId || base.Apis[0].KongId != service.Apis[0].KongId {
+		t.Error("default workspace must keep the base ids")
+	}
+	if tenant.workspaceId != "9b1d2c3e-0000-4000-8000-000000000001" ||
+		tenant.ProdServer.ServiceId != deriveId(service.ProdServer.ServiceId, tenant.workspaceId) ||
+		tenant.Apis[0].KongId != deriveId(service.Apis[0].KongId, tenant.workspaceId) {
+		t.Errorf("tenant ids = %s, %s", tenant.ProdServer.ServiceId, tenant.Apis[0].KongId)
+	}
+	if service.Apis[0].KongId == tenant.Apis[0].KongId {
+		t.Error("scoped service must not modify the base apis")
+	}
+	if other.workspaceId != apiList.WorkSpaceId || other.title() != "other()" || tenant.title() != "sample(商品API) @tenant-a" {
+		t.Errorf("titles = %s, %s", other.title(), tenant.title())
+	}
+
+	// PROCESS: 未定義のworkspace
+	apiList.Services[0].Workspaces = []string{"tenant-b"}
+	if _, err := apiList.scopedServices(); err == nil {
+		t.Error("undefined workspace must be an error")
+	}
+}